@@ -4,16 +4,21 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+	// game drivers register themselves for their game types
+	_ "github.com/Sackbuoy/gameserver-operator/internal/games/generic"
+	_ "github.com/Sackbuoy/gameserver-operator/internal/games/minecraft"
+	_ "github.com/Sackbuoy/gameserver-operator/internal/games/source"
 	"github.com/Sackbuoy/gameserver-operator/internal/manager"
 	"github.com/Sackbuoy/gameserver-operator/internal/reconciler"
 	"github.com/Sackbuoy/gameserver-operator/internal/watcher"
 	"go.uber.org/zap"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -33,6 +38,24 @@ func main() {
 		kubeconfig = flag.String("kubeconfig", "", "absolute path to the kubeconfig file")
 	}
 
+	defaultValuesFile := flag.String("default-values", "", "(optional) path to a YAML file of chart values applied beneath the values of every GameServer")
+
+	dryRun := flag.Bool("dry-run", false, "render GameServers into their dry-run ConfigMaps instead of deploying them")
+
+	gcInterval := flag.Duration("gc-interval", 10*time.Minute, "how often to uninstall releases whose GameServer no longer exists, 0 disables it")
+
+	gcDryRun := flag.Bool("gc-dry-run", false, "report orphaned releases instead of uninstalling them")
+
+	releaseNaming := flag.String("release-naming", manager.ReleaseNamingName, "how releases are named when spec.helmChart.releaseName isn't set: 'name' or 'name-hash'")
+
+	storageDriver := flag.String("helm-driver", os.Getenv("HELM_DRIVER"), "Helm storage driver releases are kept in unless a GameServer picks one: 'secret', 'configmap' or 'sql'; 'configmap' is refused for GameServers with values from Secrets")
+
+	maxHistory := flag.Int("max-history", 10, "number of revisions kept per release unless a GameServer sets spec.helmChart.maxHistory")
+
+	minKnownGood := flag.Int("min-known-good", 2, "number of successfully deployed revisions history pruning always keeps")
+
+	chartCacheTTL := flag.Duration("chart-cache-ttl", 10*time.Minute, "how long charts downloaded from repositories are reused before downloading them again, 0 disables caching")
+
 	flag.Parse()
 
 	// Try to use in-cluster config first, fall back to kubeconfig file
@@ -52,11 +75,7 @@ func main() {
 	}
 
 	// Define the resource to watch - Game CRD
-	gvc := schema.GroupVersionResource{
-		Group:    "goopy.us",
-		Version:  "v1",
-		Resource: "gameservers",
-	}
+	gvc := crds.GameServerResource
 
 	logger.Info("Watching for GameServer instances...")
 
@@ -79,11 +98,25 @@ func main() {
 		logger.Error("Failed to make CRD instance map", zap.Error(err))
 	}
 
-	manager, err := manager.New(dynamicClient, logger, instanceMap)
+	manager, err := manager.New(dynamicClient, logger, instanceMap, manager.Options{
+		DefaultValuesFile: *defaultValuesFile,
+		DryRun:            *dryRun,
+		GCInterval:        *gcInterval,
+		GCDryRun:          *gcDryRun,
+		ReleaseNaming:     *releaseNaming,
+		StorageDriver:     *storageDriver,
+		MaxHistory:        *maxHistory,
+		MinKnownGood:      *minKnownGood,
+		ChartCacheTTL:     *chartCacheTTL,
+	})
 	if err != nil {
 		logger.Fatal("Error creating manager", zap.Error(err))
 	}
 
+	sourceWatcher := watcher.NewSourceWatcher(logger, dynamicClient, manager)
+
+	commandWatcher := watcher.NewCommandWatcher(logger, dynamicClient, manager)
+
 	watcher, err := watcher.New(ctx, logger, dynamicClient, gvc, manager)
 	if err != nil {
 		logger.Fatal("Error creating watcher", zap.Error(err))
@@ -98,9 +131,32 @@ func main() {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		watcher.Watch()
+		watcher.Watch(ctx)
 	}()
 
+	// Watch Secrets and ConfigMaps referenced through valuesFrom
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sourceWatcher.Watch(ctx)
+	}()
+
+	// Run console commands submitted through GameServerCommands
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		commandWatcher.Watch(ctx)
+	}()
+
+	// Uninstall releases whose GameServer was deleted while nobody watched
+	if *gcInterval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			manager.RunGarbageCollector(ctx)
+		}()
+	}
+
 	// periodically loop through CRD instances to ensure corresponding resources
 	// are synced
 	wg.Add(1)
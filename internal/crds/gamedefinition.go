@@ -0,0 +1,77 @@
+package crds
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GameDefinitionResource is the resource GameDefinitions are served under.
+var GameDefinitionResource = schema.GroupVersionResource{
+	Group:    "goopy.us",
+	Version:  "v1",
+	Resource: "gamedefinitions",
+}
+
+// GameDefinition is a cluster-scoped catalog entry describing how to deploy a
+// game type. Its name is the gameType GameServers refer to.
+type GameDefinition struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec GameDefinitionSpec `json:"spec,omitempty"`
+}
+
+// GameDefinitionSpec defines how a game type is deployed.
+type GameDefinitionSpec struct {
+	// Chart the game type is deployed with
+	Chart ChartSource `json:"chart"`
+
+	// DefaultValues are chart values applied beneath every GameServer's own, stored as a YAML string
+	DefaultValues string `json:"defaultValues,omitempty"`
+
+	// Ports the game listens on, used when a GameServer doesn't list any
+	Ports []PortConfig `json:"ports,omitempty"`
+
+	// Persistence needs of the game
+	Persistence *PersistenceRequirements `json:"persistence,omitempty"`
+
+	// ValueMapping maps typed GameServer spec fields to chart values paths,
+	// replacing the mapping shipped with the chart
+	ValueMapping map[string]string `json:"valueMapping,omitempty"`
+
+	// ValuesSchema is a JSON schema the merged values of every GameServer of
+	// this type must satisfy, in addition to the chart's values.schema.json
+	ValuesSchema string `json:"valuesSchema,omitempty"`
+}
+
+// ChartSource locates the Helm chart of a game type.
+type ChartSource struct {
+	// Path of a chart bundled with the operator image (e.g., '/charts/minecraft-java')
+	Path string `json:"path,omitempty"`
+
+	// Repository is the URL of the Helm chart repository
+	Repository string `json:"repository,omitempty"`
+
+	// Name of the Helm chart in the repository
+	Name string `json:"name,omitempty"`
+
+	// VersionRange is the semver range of chart versions GameServers may use (e.g., '>=4.0.0 <5.0.0')
+	VersionRange string `json:"versionRange,omitempty"`
+}
+
+// PersistenceRequirements describes the storage needs of a game type.
+type PersistenceRequirements struct {
+	// Required rejects GameServers that disable persistence, and enables it
+	// for those that leave it unset
+	Required bool `json:"required,omitempty"`
+
+	// Size of the persistent volume used when a GameServer doesn't set one
+	Size string `json:"size,omitempty"`
+}
+
+// GameDefinitionList contains a list of GameDefinition resources.
+type GameDefinitionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GameDefinition `json:"items"`
+}
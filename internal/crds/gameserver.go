@@ -2,8 +2,16 @@ package crds
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
+// GameServerResource is the resource GameServers are served under.
+var GameServerResource = schema.GroupVersionResource{
+	Group:    "goopy.us",
+	Version:  "v1",
+	Resource: "gameservers",
+}
+
 // GameServer defines the schema for the GameServer custom resource.
 type GameServer struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -15,8 +23,36 @@ type GameServer struct {
 
 // GameServerSpec defines the desired state of a GameServer.
 type GameServerSpec struct {
+	// TemplateRef points at a template this spec is merged on top of
+	TemplateRef *TemplateReference `json:"templateRef,omitempty"`
+
 	// Type of game server (minecraft, valheim, etc.)
-	GameType string `json:"gameType"`
+	GameType string `json:"gameType,omitempty"`
+
+	// AdoptExisting takes over a release of the same name that was installed
+	// outside the operator, instead of failing
+	AdoptExisting bool `json:"adoptExisting,omitempty"`
+
+	// DeletionPolicy decides what happens to the release and its data when
+	// the GameServer is deleted (Delete, RetainData, Snapshot, Orphan).
+	// Defaults to RetainData. The goopy.us/release finalizer holds the
+	// deletion until the policy has run.
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+
+	// RCON configures how the operator reaches the game's console
+	RCON *RCONConfig `json:"rcon,omitempty"`
+
+	// Shutdown configures how the game is stopped before the operator
+	// uninstalls, upgrades or restarts it
+	Shutdown *ShutdownConfig `json:"shutdown,omitempty"`
+
+	// Players lists who may join the game, who operates it and who is
+	// banned from it
+	Players *PlayersConfig `json:"players,omitempty"`
+
+	// GameConfig holds game settings, rendered by the game's driver into the
+	// config files the game reads, such as server.properties
+	GameConfig map[string]any `json:"gameConfig,omitempty"`
 
 	// HelmChart contains the details of the Helm chart to deploy
 	HelmChart HelmChart `json:"helmChart,omitempty"`
@@ -29,24 +65,121 @@ type GameServerSpec struct {
 
 	// Networking configuration for the game server
 	Networking *NetworkingConfig `json:"networking,omitempty"`
+
+	// PostRender patches the rendered chart manifests before they are deployed
+	PostRender *PostRender `json:"postRender,omitempty"`
+}
+
+// RCONConfig configures the RCON console of a game server.
+type RCONConfig struct {
+	// PasswordSecret is the Secret holding the RCON password under its
+	// 'password' key (defaults to '<name>-rcon', which the operator
+	// generates)
+	PasswordSecret string `json:"passwordSecret,omitempty"`
+
+	// Port of the RCON Service port, instead of the game driver's default
+	Port int32 `json:"port,omitempty"`
+}
+
+// ShutdownConfig configures the graceful shutdown of a game server.
+type ShutdownConfig struct {
+	// Countdown in seconds players are warned for before the game stops,
+	// skipped when nobody is online (defaults to 60)
+	Countdown int `json:"countdown,omitempty"`
+
+	// Timeout in seconds to wait for the game to stop once told to (defaults to 60)
+	Timeout int `json:"timeout,omitempty"`
+
+	// Disabled hands the game to Helm without stopping it first
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// PlayersConfig declares the player lists of a game server. Lists left out
+// aren't managed, apart from removing the players the operator added to them.
+type PlayersConfig struct {
+	// Allowlist names the players allowed to join, when the game enforces
+	// its allowlist
+	Allowlist []string `json:"allowlist,omitempty"`
+
+	// Ops names the players with operator permissions
+	Ops []string `json:"ops,omitempty"`
+
+	// Bans names the players kept out of the game
+	Bans []string `json:"bans,omitempty"`
+}
+
+// TemplateReference points at a GameServerTemplate or ClusterGameServerTemplate.
+type TemplateReference struct {
+	// Kind of the template (GameServerTemplate, ClusterGameServerTemplate)
+	Kind string `json:"kind,omitempty"`
+
+	// Name of the template, in the GameServer's namespace for GameServerTemplates
+	Name string `json:"name"`
+
+	// AutoUpdate rolls template changes out to this GameServer as they happen.
+	// Otherwise they are picked up the next time the GameServer itself changes.
+	AutoUpdate bool `json:"autoUpdate,omitempty"`
 }
 
 // HelmChart contains details about a Helm chart to deploy.
 type HelmChart struct {
 	// Repository is the URL of the Helm chart repository
-	Repository string `json:"repository"`
+	Repository string `json:"repository,omitempty"`
 
 	// Name of the Helm chart
-	Name string `json:"name"`
+	Name string `json:"name,omitempty"`
 
 	// Version of the Helm chart to use
-	Version string `json:"version"`
+	Version string `json:"version,omitempty"`
+
+	// ReleaseName names the Helm release explicitly, instead of the operator's
+	// naming strategy. It can't change once the release is deployed.
+	ReleaseName string `json:"releaseName,omitempty"`
 
 	// ValuesOverride contains Helm chart values to override, stored as a YAML string
 	ValuesOverride string `json:"valuesOverride,omitempty"`
 
-	// Timeout for Helm operations in seconds
+	// ValuesFrom lists Secrets and ConfigMaps to pull chart values from. They
+	// are merged in order, and ValuesOverride is merged on top of them.
+	ValuesFrom []ValuesReference `json:"valuesFrom,omitempty"`
+
+	// Timeout in seconds for installs and upgrades to become ready before they
+	// are rolled back
 	Timeout int `json:"timeout,omitempty"`
+
+	// StorageDriver is the Helm storage driver the release is kept in
+	// (secret, configmap, sql), instead of the operator's default. It can't
+	// change once the release is deployed. Helm stores the values of every
+	// revision with it, so configmap is refused for GameServers whose values
+	// come from Secrets, the RCON password included.
+	StorageDriver string `json:"storageDriver,omitempty"`
+
+	// MaxHistory is how many revisions of the release are kept, instead of
+	// the operator's default
+	MaxHistory int `json:"maxHistory,omitempty"`
+
+	// RunTests runs the chart's Helm tests after each successful install or
+	// upgrade
+	RunTests bool `json:"runTests,omitempty"`
+}
+
+// ValuesReference points at a key of a Secret or ConfigMap holding chart values.
+type ValuesReference struct {
+	// Kind of the referenced object (Secret, ConfigMap)
+	Kind string `json:"kind"`
+
+	// Name of the referenced object, in the GameServer's namespace
+	Name string `json:"name"`
+
+	// Key holding the values (defaults to 'values.yaml')
+	Key string `json:"key,omitempty"`
+
+	// TargetPath is a dot separated values path the key's content is set at,
+	// verbatim. When empty the content is parsed as YAML and merged at the root.
+	TargetPath string `json:"targetPath,omitempty"`
+
+	// Optional marks the reference as optional, missing objects or keys are skipped
+	Optional bool `json:"optional,omitempty"`
 }
 
 // ResourceRequirements describes the compute resource requirements.
@@ -112,6 +245,33 @@ type PortConfig struct {
 	NodePort int32 `json:"nodePort,omitempty"`
 }
 
+// PostRender defines Kustomize patches applied to the rendered chart manifests.
+type PostRender struct {
+	// Patches are applied in order
+	Patches []Patch `json:"patches,omitempty"`
+}
+
+// Patch is a strategic-merge or JSON6902 patch, in the form of a Kustomize
+// patches entry.
+type Patch struct {
+	// Patch is a strategic-merge patch, or a list of JSON6902 operations, as YAML
+	Patch string `json:"patch"`
+
+	// Target selects the resources to patch, required for JSON6902 patches
+	Target *PatchTarget `json:"target,omitempty"`
+}
+
+// PatchTarget selects resources the way a Kustomize patch target does.
+type PatchTarget struct {
+	Group              string `json:"group,omitempty"`
+	Version            string `json:"version,omitempty"`
+	Kind               string `json:"kind,omitempty"`
+	Name               string `json:"name,omitempty"`
+	Namespace          string `json:"namespace,omitempty"`
+	LabelSelector      string `json:"labelSelector,omitempty"`
+	AnnotationSelector string `json:"annotationSelector,omitempty"`
+}
+
 // GameServerStatus defines the observed state of a GameServer.
 type GameServerStatus struct {
 	// Current phase of the game server (Pending, Deploying, Running, Failed, etc.)
@@ -120,15 +280,40 @@ type GameServerStatus struct {
 	// Human-readable message about the current state
 	Message string `json:"message,omitempty"`
 
+	// ObservedGeneration is the most recent generation deployed by the operator
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
 	// HelmRelease contains information about the Helm release
 	HelmRelease *HelmReleaseStatus `json:"helmRelease,omitempty"`
 
+	// FailedRelease records the last install or upgrade that failed, which
+	// isn't retried until the spec or the values change
+	FailedRelease *FailedReleaseStatus `json:"failedRelease,omitempty"`
+
+	// Template records the template the deployed spec was merged from
+	Template *TemplateStatus `json:"template,omitempty"`
+
 	// Deployment contains information about the deployment
 	Deployment *DeploymentStatus `json:"deployment,omitempty"`
 
 	// Networking contains information about the service
 	Networking *NetworkingStatus `json:"networking,omitempty"`
 
+	// ConnectAddress is where players connect to (e.g., 'mc.example.com:25565')
+	ConnectAddress string `json:"connectAddress,omitempty"`
+
+	// Game contains what the game server answered to its last query
+	Game *GameStatus `json:"game,omitempty"`
+
+	// RCON records rotations of the RCON password
+	RCON *RCONStatus `json:"rcon,omitempty"`
+
+	// Players records the player lists applied to the game
+	Players *PlayersStatus `json:"players,omitempty"`
+
+	// GameConfig records the game settings put into effect
+	GameConfig *GameConfigStatus `json:"gameConfig,omitempty"`
+
 	// Conditions is a list of current conditions
 	Conditions []GameServerCondition `json:"conditions,omitempty"`
 
@@ -136,6 +321,46 @@ type GameServerStatus struct {
 	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
 }
 
+// RCONStatus records rotations of the RCON password.
+type RCONStatus struct {
+	// RotationRequest is the last value of the goopy.us/rotate-rcon
+	// annotation the operator acted on
+	RotationRequest string `json:"rotationRequest,omitempty"`
+
+	// LastRotated is the last time the password was rotated
+	LastRotated *metav1.Time `json:"lastRotated,omitempty"`
+}
+
+// PlayersStatus records the player lists applied to the running game.
+type PlayersStatus struct {
+	// Allowlist, Ops and Bans are the players the operator put on each list
+	Allowlist []string `json:"allowlist,omitempty"`
+	Ops       []string `json:"ops,omitempty"`
+	Bans      []string `json:"bans,omitempty"`
+
+	// Drift lists how the game's lists differed from the spec when the
+	// operator last changed them, such as players added in game
+	Drift []string `json:"drift,omitempty"`
+
+	// LastChanged is the last time the operator changed the game's lists
+	LastChanged *metav1.Time `json:"lastChanged,omitempty"`
+}
+
+// GameConfigStatus records the game settings put into effect.
+type GameConfigStatus struct {
+	// ConfigMap holds the rendered config files
+	ConfigMap string `json:"configMap,omitempty"`
+
+	// Hash is the content hash of the rendered config files
+	Hash string `json:"hash,omitempty"`
+
+	// Settings are the settings in effect, as rendered
+	Settings map[string]string `json:"settings,omitempty"`
+
+	// LastApplied is the last time settings were put into effect
+	LastApplied *metav1.Time `json:"lastApplied,omitempty"`
+}
+
 // HelmReleaseStatus contains information about a Helm release.
 type HelmReleaseStatus struct {
 	// Name of the Helm release
@@ -146,6 +371,47 @@ type HelmReleaseStatus struct {
 
 	// LastDeployed is the last time the Helm release was deployed
 	LastDeployed *metav1.Time `json:"lastDeployed,omitempty"`
+
+	// ValuesHash is the content hash of the values the release was deployed with
+	ValuesHash string `json:"valuesHash,omitempty"`
+
+	// ValuesConfigMap names the ConfigMap holding the deployed values,
+	// annotated with the source of every value
+	ValuesConfigMap string `json:"valuesConfigMap,omitempty"`
+
+	// StorageDriver is the Helm storage driver the release is kept in
+	StorageDriver string `json:"storageDriver,omitempty"`
+}
+
+// FailedReleaseStatus records an install or upgrade that failed.
+type FailedReleaseStatus struct {
+	// Generation of the GameServer that failed to deploy
+	Generation int64 `json:"generation,omitempty"`
+
+	// ValuesHash is the content hash of the values that failed to deploy
+	ValuesHash string `json:"valuesHash,omitempty"`
+
+	// Reason the release failed, such as UpgradeFailed
+	Reason string `json:"reason,omitempty"`
+
+	// FailedAt is when the release failed
+	FailedAt *metav1.Time `json:"failedAt,omitempty"`
+}
+
+// TemplateStatus records the template a GameServer was last deployed from.
+type TemplateStatus struct {
+	// Kind of the template
+	Kind string `json:"kind,omitempty"`
+
+	// Name of the template
+	Name string `json:"name,omitempty"`
+
+	// Generation of the template that was deployed
+	Generation int64 `json:"generation,omitempty"`
+
+	// Spec is the template spec that was deployed, reused until the
+	// GameServer changes when autoUpdate is off
+	Spec *GameServerSpec `json:"spec,omitempty"`
 }
 
 // DeploymentStatus contains information about a deployment.
@@ -161,6 +427,64 @@ type DeploymentStatus struct {
 
 	// Number of updated replicas
 	UpdatedReplicas int32 `json:"updatedReplicas,omitempty"`
+
+	// Workloads of the release the counts are summed over
+	Workloads []WorkloadStatus `json:"workloads,omitempty"`
+
+	// Total container restarts across the pods of the release
+	RestartCount int32 `json:"restartCount,omitempty"`
+
+	// Reason the most recently terminated container of the release stopped
+	// (e.g., 'OOMKilled', 'Error')
+	LastTerminationReason string `json:"lastTerminationReason,omitempty"`
+
+	// Pods of the release
+	Pods []PodStatus `json:"pods,omitempty"`
+}
+
+// WorkloadStatus contains the status of a Deployment or StatefulSet of the release.
+type WorkloadStatus struct {
+	// Kind of the workload (Deployment, StatefulSet)
+	Kind string `json:"kind"`
+
+	// Name of the workload
+	Name string `json:"name"`
+
+	// Desired number of replicas
+	DesiredReplicas int32 `json:"desiredReplicas"`
+
+	// Number of ready replicas
+	ReadyReplicas int32 `json:"readyReplicas"`
+
+	// Number of available replicas
+	AvailableReplicas int32 `json:"availableReplicas"`
+}
+
+// PodStatus contains the details of a pod of the release.
+type PodStatus struct {
+	// Name of the pod
+	Name string `json:"name"`
+
+	// Phase of the pod
+	Phase string `json:"phase,omitempty"`
+
+	// Whether all containers of the pod are ready
+	Ready bool `json:"ready"`
+
+	// Container restarts of the pod
+	RestartCount int32 `json:"restartCount,omitempty"`
+
+	// Reason a container of the pod is waiting (e.g., 'CrashLoopBackOff')
+	WaitingReason string `json:"waitingReason,omitempty"`
+
+	// Reason the last terminated container of the pod stopped
+	LastTerminationReason string `json:"lastTerminationReason,omitempty"`
+
+	// Exit code of the last terminated container of the pod
+	LastTerminationExitCode int32 `json:"lastTerminationExitCode,omitempty"`
+
+	// When the last terminated container of the pod stopped
+	LastTerminationTime *metav1.Time `json:"lastTerminationTime,omitempty"`
 }
 
 // NetworkingStatus contains information about the service.
@@ -174,6 +498,32 @@ type NetworkingStatus struct {
 	// External IP for LoadBalancer service
 	ExternalIP string `json:"externalIP,omitempty"`
 
+	// External hostname for LoadBalancer service
+	ExternalHostname string `json:"externalHostname,omitempty"`
+
+	// Ports exposed by the service
+	Ports []PortStatus `json:"ports,omitempty"`
+
+	// Services of the release, the fields above describe the one players
+	// connect to
+	Services []ServiceStatus `json:"services,omitempty"`
+}
+
+// ServiceStatus contains information about a Service of the release.
+type ServiceStatus struct {
+	// Name of the service
+	Name string `json:"name"`
+
+	// Type of the service
+	Type string `json:"type,omitempty"`
+
+	// Cluster IP of the service
+	ClusterIP string `json:"clusterIP,omitempty"`
+
+	// External IPs and hostnames of the service, from its LoadBalancer
+	// ingress and spec.externalIPs
+	ExternalAddresses []string `json:"externalAddresses,omitempty"`
+
 	// Ports exposed by the service
 	Ports []PortStatus `json:"ports,omitempty"`
 }
@@ -196,6 +546,30 @@ type PortStatus struct {
 	Protocol string `json:"protocol,omitempty"`
 }
 
+// GameStatus contains what a game server reports about itself.
+type GameStatus struct {
+	// Name the game server advertises
+	Name string `json:"name,omitempty"`
+
+	// Map the game server runs
+	Map string `json:"map,omitempty"`
+
+	// Version of the game server
+	Version string `json:"version,omitempty"`
+
+	// Message of the day
+	MOTD string `json:"motd,omitempty"`
+
+	// Number of players online
+	OnlinePlayers int32 `json:"onlinePlayers"`
+
+	// Maximum number of players
+	MaxPlayers int32 `json:"maxPlayers"`
+
+	// Names of online players, as far as the server lists them
+	Players []string `json:"players,omitempty"`
+}
+
 // GameServerCondition contains condition information for a GameServer.
 type GameServerCondition struct {
 	// Type of condition
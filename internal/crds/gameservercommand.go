@@ -0,0 +1,64 @@
+package crds
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GameServerCommandResource is the resource GameServerCommands are served under.
+var GameServerCommandResource = schema.GroupVersionResource{
+	Group:    "goopy.us",
+	Version:  "v1",
+	Resource: "gameservercommands",
+}
+
+// GameServerCommand runs a console command on a GameServer once, recording
+// its output.
+type GameServerCommand struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GameServerCommandSpec   `json:"spec,omitempty"`
+	Status GameServerCommandStatus `json:"status,omitempty"`
+}
+
+// GameServerCommandSpec defines the command to run.
+type GameServerCommandSpec struct {
+	// GameServer is the name of the GameServer in the same namespace to run
+	// the command on
+	GameServer string `json:"gameServer"`
+
+	// Command is the console command (e.g., 'whitelist add Steve')
+	Command string `json:"command"`
+
+	// Timeout in seconds for connecting and running the command
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// GameServerCommandStatus records the outcome of a command.
+type GameServerCommandStatus struct {
+	// Phase of the command (Running, Succeeded, Failed)
+	Phase string `json:"phase,omitempty"`
+
+	// Reason the command ended in its phase
+	Reason string `json:"reason,omitempty"`
+
+	// Human-readable message about the outcome
+	Message string `json:"message,omitempty"`
+
+	// Response is the output of the command, the tail of it when long
+	Response string `json:"response,omitempty"`
+
+	// StartedAt is when the operator started running the command
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// CompletedAt is when the command succeeded or failed
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+}
+
+// GameServerCommandList contains a list of GameServerCommand resources.
+type GameServerCommandList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GameServerCommand `json:"items"`
+}
@@ -0,0 +1,41 @@
+package crds
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	GameServerTemplateKind        = "GameServerTemplate"
+	ClusterGameServerTemplateKind = "ClusterGameServerTemplate"
+)
+
+// GameServerTemplateResource is the resource namespaced templates are served under.
+var GameServerTemplateResource = schema.GroupVersionResource{
+	Group:    "goopy.us",
+	Version:  "v1",
+	Resource: "gameservertemplates",
+}
+
+// ClusterGameServerTemplateResource is the resource cluster-scoped templates are served under.
+var ClusterGameServerTemplateResource = schema.GroupVersionResource{
+	Group:    "goopy.us",
+	Version:  "v1",
+	Resource: "clustergameservertemplates",
+}
+
+// GameServerTemplate holds a GameServer spec that GameServers can start from
+// through spec.templateRef. ClusterGameServerTemplates share the same schema.
+type GameServerTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec GameServerSpec `json:"spec,omitempty"`
+}
+
+// GameServerTemplateList contains a list of GameServerTemplate resources.
+type GameServerTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GameServerTemplate `json:"items"`
+}
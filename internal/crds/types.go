@@ -2,6 +2,8 @@ package crds
 
 import "sync"
 
+// CRDInstanceMap tracks GameServers by namespace/name, their release names
+// may differ.
 type CRDInstanceMap struct {
   instances map[string]*GameServer
   accessMut sync.Mutex
@@ -15,36 +17,43 @@ func NewInstanceMap() (*CRDInstanceMap, error) {
   }, nil
 }
 
+func instanceKey(namespace, name string) string {
+  return namespace + "/" + name
+}
+
 func (m *CRDInstanceMap) Create(instance *GameServer) error {
   m.accessMut.Lock()
-  m.instances[instance.Name] = instance
+  m.instances[instanceKey(instance.Namespace, instance.Name)] = instance
   m.accessMut.Unlock()
   return nil
 }
 
 func (m *CRDInstanceMap) Update(instance *GameServer) error {
   m.accessMut.Lock()
-  m.instances[instance.Name] = instance
+  m.instances[instanceKey(instance.Namespace, instance.Name)] = instance
   m.accessMut.Unlock()
   return nil
 }
 
-func (m *CRDInstanceMap) Delete(name string) error {
+func (m *CRDInstanceMap) Delete(namespace, name string) error {
   m.accessMut.Lock()
-  delete(m.instances, name)
+  delete(m.instances, instanceKey(namespace, name))
   m.accessMut.Unlock()
   return nil
 }
 
-func (m *CRDInstanceMap) Read(name string) *GameServer {
-  val, ok := m.instances[name]; if !ok {
+func (m *CRDInstanceMap) Read(namespace, name string) *GameServer {
+  val, ok := m.instances[instanceKey(namespace, name)]; if !ok {
     return nil
   }
   return val
 }
 
 func (m *CRDInstanceMap) List() []*GameServer {
-  result := make([]*GameServer, len(m.instances))
+  m.accessMut.Lock()
+  defer m.accessMut.Unlock()
+
+  result := make([]*GameServer, 0, len(m.instances))
   for _, instance := range m.instances {
     result = append(result, instance)
   }
@@ -0,0 +1,99 @@
+package games
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FormatValue renders a scalar setting the way config files spell it.
+func FormatValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("unsupported setting value %v of type %T", value, value)
+	}
+}
+
+// RenderProperties renders settings as a Java properties file, sorted by key.
+func RenderProperties(settings map[string]any) (string, error) {
+	keys := sortedKeys(settings)
+
+	var rendered strings.Builder
+
+	for _, key := range keys {
+		value, err := FormatValue(settings[key])
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", key, err)
+		}
+
+		fmt.Fprintf(&rendered, "%s=%s\n", escapeProperty(key, true), escapeProperty(value, false))
+	}
+
+	return rendered.String(), nil
+}
+
+// RenderJSON renders settings as an indented JSON document.
+func RenderJSON(settings map[string]any) (string, error) {
+	rendered, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(rendered) + "\n", nil
+}
+
+func escapeProperty(text string, key bool) string {
+	var escaped strings.Builder
+
+	for i, r := range text {
+		switch {
+		case r == '\\':
+			escaped.WriteString(`\\`)
+		case r == '\n':
+			escaped.WriteString(`\n`)
+		case r == '\r':
+			escaped.WriteString(`\r`)
+		case r == '\t':
+			escaped.WriteString(`\t`)
+		case key && (r == '=' || r == ':' || r == ' '):
+			escaped.WriteRune('\\')
+			escaped.WriteRune(r)
+		case (key || i == 0) && (r == '#' || r == '!'):
+			escaped.WriteRune('\\')
+			escaped.WriteRune(r)
+		case !key && i == 0 && r == ' ':
+			escaped.WriteString(`\ `)
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+
+	return escaped.String()
+}
+
+func sortedKeys(settings map[string]any) []string {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,130 @@
+// Package games holds the game-specific behavior of the operator. Every game
+// type is served by a Driver, registered under the gameType of the
+// GameServers it serves by the package implementing it.
+package games
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+// GenericTCP is the game type of the driver serving game types no other
+// driver is registered for.
+const GenericTCP = "generic-tcp"
+
+// Driver implements what the operator does differently per game.
+type Driver interface {
+	// QueryPort is the Service port the game answers Probe on.
+	QueryPort() Port
+
+	// ConsolePort is the Service port of the game's RCON console, ok is false
+	// for games without one.
+	ConsolePort() (port Port, ok bool)
+
+	// Probe queries the game at address (host:port) and reports what it
+	// answered.
+	Probe(ctx context.Context, address string) (*crds.GameStatus, error)
+
+	// Broadcast shows a message to every player through the game's console.
+	Broadcast(ctx context.Context, console Console, message string) error
+
+	// PrepareBackup makes the game's data on disk consistent for a backup,
+	// and returns a func to call once the backup has been taken.
+	PrepareBackup(ctx context.Context, console Console) (resume func(context.Context) error, err error)
+
+	// RenderConfig renders game settings into the files the game reads them
+	// from, keyed by file name.
+	RenderConfig(settings map[string]any) (map[string]string, error)
+}
+
+// Stopper is implemented by drivers that can have their game save and shut
+// down through its console. The games of other drivers stop with their pods.
+type Stopper interface {
+	// Stop asks the game to save and shut down through its console.
+	Stop(ctx context.Context, console Console) error
+}
+
+// RCONValues is implemented by drivers that know where the charts usually
+// deployed for their game read the RCON password from.
+type RCONValues interface {
+	// RCONPasswordPath is the dotted values path of the RCON password.
+	RCONPasswordPath() string
+}
+
+// LiveSettings is implemented by drivers that can change some settings of a
+// running game through its console, rather than by restarting it.
+type LiveSettings interface {
+	// RestartRequired reports whether a setting only takes effect once the
+	// game restarts.
+	RestartRequired(key string) bool
+
+	// ApplySetting changes a setting of the running game.
+	ApplySetting(ctx context.Context, console Console, key string, value any) error
+}
+
+// Port identifies a Service port by number or name.
+type Port struct {
+	// Protocol is TCP or UDP
+	Protocol string
+	Number   int32
+	// Name matches port names containing it
+	Name string
+}
+
+// Console runs commands on a game server, such as over RCON.
+type Console interface {
+	Command(ctx context.Context, command string) (string, error)
+}
+
+var (
+	mu      sync.RWMutex
+	drivers = make(map[string]Driver)
+)
+
+// Register makes a driver available for a game type. Driver packages call it
+// from init, registering a game type twice panics.
+func Register(gameType string, driver Driver) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if driver == nil {
+		panic("games: Register driver is nil")
+	}
+
+	if _, dup := drivers[gameType]; dup {
+		panic("games: Register called twice for " + gameType)
+	}
+
+	drivers[gameType] = driver
+}
+
+// Lookup returns the driver of a game type, falling back to the generic TCP
+// driver. It is nil when not even that one is registered.
+func Lookup(gameType string) Driver {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if driver, ok := drivers[gameType]; ok {
+		return driver
+	}
+
+	return drivers[GenericTCP]
+}
+
+// GameTypes lists the game types drivers are registered for.
+func GameTypes() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	gameTypes := make([]string, 0, len(drivers))
+	for gameType := range drivers {
+		gameTypes = append(gameTypes, gameType)
+	}
+
+	sort.Strings(gameTypes)
+
+	return gameTypes
+}
@@ -0,0 +1,137 @@
+package games_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/games"
+	"github.com/Sackbuoy/gameserver-operator/internal/games/generic"
+	"github.com/Sackbuoy/gameserver-operator/internal/games/minecraft"
+	"github.com/Sackbuoy/gameserver-operator/internal/games/source"
+)
+
+func TestRegisteredDrivers(t *testing.T) {
+	gameTypes := games.GameTypes()
+
+	for _, gameType := range []string{games.GenericTCP, generic.GenericUDP, minecraft.GameType, "source", "cs2", "rust"} {
+		if !slices.Contains(gameTypes, gameType) {
+			t.Errorf("no driver registered for %s, have %v", gameType, gameTypes)
+		}
+	}
+
+	if !slices.IsSorted(gameTypes) {
+		t.Errorf("GameTypes not sorted: %v", gameTypes)
+	}
+}
+
+func TestRegisterTwicePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a game type twice didn't panic")
+		}
+	}()
+
+	games.Register(minecraft.GameType, minecraft.Driver{})
+}
+
+func TestLookup(t *testing.T) {
+	if _, ok := games.Lookup(minecraft.GameType).(minecraft.Driver); !ok {
+		t.Errorf("Lookup(%q) = %T, want minecraft.Driver", minecraft.GameType, games.Lookup(minecraft.GameType))
+	}
+
+	if driver, ok := games.Lookup("rust").(source.Driver); !ok || driver.Port != 28015 {
+		t.Errorf("Lookup(rust) = %#v, want the source driver on port 28015", games.Lookup("rust"))
+	}
+
+	for _, gameType := range []string{"", "terraria"} {
+		driver, ok := games.Lookup(gameType).(generic.Driver)
+		if !ok || driver.Protocol != "TCP" {
+			t.Errorf("Lookup(%q) = %#v, want the generic TCP driver", gameType, games.Lookup(gameType))
+		}
+	}
+}
+
+func TestStoppers(t *testing.T) {
+	if _, ok := games.Lookup(minecraft.GameType).(games.Stopper); !ok {
+		t.Errorf("%s can't be stopped through its console", minecraft.GameType)
+	}
+
+	// their games stop with their pods, waiting on them would only time out
+	for _, gameType := range []string{"source", "cs2", "rust", "valheim", games.GenericTCP, generic.GenericUDP} {
+		if _, ok := games.Lookup(gameType).(games.Stopper); ok {
+			t.Errorf("%s claims to stop its game", gameType)
+		}
+	}
+}
+
+func TestRenderConfig(t *testing.T) {
+	settings := map[string]any{
+		"motd":        "Hello: world",
+		"max-players": int64(20),
+		"pvp":         false,
+	}
+
+	tests := []struct {
+		gameType string
+		file     string
+		want     string
+	}{
+		{
+			gameType: minecraft.GameType,
+			file:     minecraft.ConfigFile,
+			want:     "max-players=20\nmotd=Hello: world\npvp=false\n",
+		},
+		{
+			gameType: "source",
+			file:     source.ConfigFile,
+			want:     "max-players \"20\"\nmotd \"Hello: world\"\npvp \"false\"\n",
+		},
+		{
+			gameType: games.GenericTCP,
+			file:     generic.ConfigFile,
+			want:     "{\n  \"max-players\": 20,\n  \"motd\": \"Hello: world\",\n  \"pvp\": false\n}\n",
+		},
+	}
+
+	for _, test := range tests {
+		files, err := games.Lookup(test.gameType).RenderConfig(settings)
+		if err != nil {
+			t.Errorf("%s: RenderConfig: %v", test.gameType, err)
+
+			continue
+		}
+
+		if len(files) != 1 || files[test.file] != test.want {
+			t.Errorf("%s: RenderConfig = %q, want %s with %q", test.gameType, files, test.file, test.want)
+		}
+	}
+}
+
+func TestRenderConfigRejects(t *testing.T) {
+	if _, err := games.Lookup(minecraft.GameType).RenderConfig(map[string]any{"nested": map[string]any{}}); err == nil {
+		t.Error("minecraft rendered a nested setting")
+	}
+
+	if _, err := games.Lookup("source").RenderConfig(map[string]any{"sv_tags": "a\";quit"}); err == nil {
+		t.Error("source rendered a setting escaping its console variable")
+	}
+}
+
+func TestRenderProperties(t *testing.T) {
+	rendered, err := games.RenderProperties(map[string]any{
+		"key with=sep": " leading space",
+		"#comment":     "!bang",
+		"path":         `C:\games` + "\n",
+	})
+	if err != nil {
+		t.Fatalf("RenderProperties: %v", err)
+	}
+
+	want := "\\#comment=\\!bang\n" +
+		"key\\ with\\=sep=\\ leading space\n" +
+		"path=C:\\\\games\\n\n"
+
+	if rendered != want {
+		t.Errorf("RenderProperties = %q, want %q", rendered, want)
+	}
+}
@@ -0,0 +1,101 @@
+// Package generic is the driver of games the operator knows nothing about,
+// which it only checks for an open port.
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+	"github.com/Sackbuoy/gameserver-operator/internal/games"
+	"github.com/Sackbuoy/gameserver-operator/internal/query"
+)
+
+// GenericUDP is the game type of the generic UDP driver.
+const GenericUDP = "generic-udp"
+
+// ConfigFile is where settings of generic games are rendered to.
+const ConfigFile = "config.json"
+
+// udpSilence is how long a UDP port may stay silent after a probe and still
+// count as open. Closed ports answer with an ICMP error well before.
+const udpSilence = time.Second
+
+func init() {
+	games.Register(games.GenericTCP, Driver{Protocol: "TCP"})
+	games.Register(GenericUDP, Driver{Protocol: "UDP"})
+}
+
+// Driver probes the first port of its protocol. Without a known console it
+// leaves stopping and backups to the pod's termination.
+type Driver struct {
+	// Protocol is TCP or UDP
+	Protocol string
+}
+
+func (d Driver) QueryPort() games.Port {
+	return games.Port{Protocol: d.Protocol}
+}
+
+func (Driver) ConsolePort() (games.Port, bool) {
+	return games.Port{}, false
+}
+
+// Probe connects to a TCP port, or sends an empty datagram to a UDP port and
+// waits for an ICMP port unreachable that never comes for an open port. An
+// open port tells nothing about the game, so there is no status to report.
+func (d Driver) Probe(ctx context.Context, address string) (*crds.GameStatus, error) {
+	if d.Protocol == "TCP" {
+		conn, err := (&net.Dialer{Timeout: query.DefaultTimeout}).DialContext(ctx, "tcp", address)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to %s failed: %w", address, err)
+		}
+
+		conn.Close()
+
+		return nil, nil
+	}
+
+	conn, err := (&net.Dialer{Timeout: query.DefaultTimeout}).DialContext(ctx, "udp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte{}); err != nil {
+		return nil, fmt.Errorf("sending to %s failed: %w", address, err)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(udpSilence)); err != nil {
+		return nil, err
+	}
+
+	_, err = conn.Read(make([]byte, 1))
+
+	var netErr net.Error
+	if err != nil && !(errors.As(err, &netErr) && netErr.Timeout()) {
+		return nil, fmt.Errorf("%s is not listening: %w", address, err)
+	}
+
+	return nil, nil
+}
+
+func (Driver) Broadcast(context.Context, games.Console, string) error {
+	return nil
+}
+
+func (Driver) PrepareBackup(context.Context, games.Console) (func(context.Context) error, error) {
+	return func(context.Context) error { return nil }, nil
+}
+
+func (Driver) RenderConfig(settings map[string]any) (map[string]string, error) {
+	rendered, err := games.RenderJSON(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", ConfigFile, err)
+	}
+
+	return map[string]string{ConfigFile: rendered}, nil
+}
@@ -0,0 +1,142 @@
+// Package minecraft is the driver of Minecraft Java servers.
+package minecraft
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+	"github.com/Sackbuoy/gameserver-operator/internal/games"
+	"github.com/Sackbuoy/gameserver-operator/internal/query"
+)
+
+// GameType is the gameType of Minecraft Java GameServers.
+const GameType = "minecraft-java"
+
+// ConfigFile is where the server reads its settings from.
+const ConfigFile = "server.properties"
+
+func init() {
+	games.Register(GameType, Driver{})
+}
+
+// Driver queries Minecraft Java servers with Server List Ping and drives
+// them through their server console.
+type Driver struct{}
+
+func (Driver) QueryPort() games.Port {
+	return games.Port{Protocol: "TCP", Number: 25565, Name: "minecraft"}
+}
+
+func (Driver) ConsolePort() (games.Port, bool) {
+	return games.Port{Protocol: "TCP", Number: 25575, Name: "rcon"}, true
+}
+
+// RCONPasswordPath is where the itzg/minecraft chart reads the password from.
+func (Driver) RCONPasswordPath() string {
+	return "minecraftServer.rcon.password"
+}
+
+func (Driver) Probe(ctx context.Context, address string) (*crds.GameStatus, error) {
+	status, err := query.PingMinecraft(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("server list ping to %s failed: %w", address, err)
+	}
+
+	return &crds.GameStatus{
+		Version:       status.Version,
+		MOTD:          status.MOTD,
+		OnlinePlayers: int32(status.OnlinePlayers),
+		MaxPlayers:    int32(status.MaxPlayers),
+		Players:       status.Players,
+	}, nil
+}
+
+func (Driver) Broadcast(ctx context.Context, console games.Console, message string) error {
+	_, err := console.Command(ctx, "say "+message)
+
+	return err
+}
+
+// Stop flushes the world to disk before stopping the server.
+func (Driver) Stop(ctx context.Context, console games.Console) error {
+	if _, err := console.Command(ctx, "save-all flush"); err != nil {
+		return fmt.Errorf("save-all failed: %w", err)
+	}
+
+	if _, err := console.Command(ctx, "stop"); err != nil {
+		return fmt.Errorf("stop failed: %w", err)
+	}
+
+	return nil
+}
+
+// PrepareBackup turns autosaving off and flushes the world, so its files
+// don't change while they are copied.
+func (Driver) PrepareBackup(ctx context.Context, console games.Console) (func(context.Context) error, error) {
+	if _, err := console.Command(ctx, "save-off"); err != nil {
+		return nil, fmt.Errorf("save-off failed: %w", err)
+	}
+
+	resume := func(ctx context.Context) error {
+		_, err := console.Command(ctx, "save-on")
+
+		return err
+	}
+
+	if _, err := console.Command(ctx, "save-all flush"); err != nil {
+		_ = resume(ctx)
+
+		return nil, fmt.Errorf("save-all failed: %w", err)
+	}
+
+	return resume, nil
+}
+
+// liveSettings are the server.properties keys a console command changes on
+// the running server, by the command setting each value. Every other key is
+// only read at startup.
+var liveSettings = map[string]func(value string) string{
+	"difficulty": func(value string) string { return "difficulty " + value },
+	"gamemode":   func(value string) string { return "defaultgamemode " + value },
+	"white-list": func(value string) string {
+		if value == "true" {
+			return "whitelist on"
+		}
+
+		return "whitelist off"
+	},
+}
+
+func (Driver) RestartRequired(key string) bool {
+	_, live := liveSettings[key]
+
+	return !live
+}
+
+func (Driver) ApplySetting(ctx context.Context, console games.Console, key string, value any) error {
+	command, ok := liveSettings[key]
+	if !ok {
+		return fmt.Errorf("%s can't be changed on a running server", key)
+	}
+
+	formatted, err := games.FormatValue(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	if _, err := console.Command(ctx, command(formatted)); err != nil {
+		return fmt.Errorf("setting %s failed: %w", key, err)
+	}
+
+	return nil
+}
+
+func (Driver) RenderConfig(settings map[string]any) (map[string]string, error) {
+	properties, err := games.RenderProperties(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{ConfigFile: properties}, nil
+}
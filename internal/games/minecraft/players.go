@@ -0,0 +1,95 @@
+package minecraft
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/games"
+)
+
+// playerName matches the names Minecraft accounts can have, anything else
+// would pass extra arguments to the commands below.
+var playerName = regexp.MustCompile(`^[A-Za-z0-9_]{1,16}$`)
+
+// playerCommands are the console commands adding and removing players of
+// each list.
+var playerCommands = map[games.PlayerList][2]string{
+	games.Allowlist: {"whitelist add", "whitelist remove"},
+	games.Ops:       {"op", "deop"},
+	games.Bans:      {"ban", "pardon"},
+}
+
+// playerFiles are the legacy list files the server converts into its JSON
+// lists, looking up the players' UUIDs, when those don't exist yet.
+var playerFiles = map[games.PlayerList]string{
+	games.Allowlist: "white-list.txt",
+	games.Ops:       "ops.txt",
+	games.Bans:      "banned-players.txt",
+}
+
+// ListPlayers reads the allowlist. The console lists neither operators nor
+// bans in a form that can be told apart reliably.
+func (Driver) ListPlayers(ctx context.Context, console games.Console) (games.PlayerLists, error) {
+	response, err := console.Command(ctx, "whitelist list")
+	if err != nil {
+		return nil, fmt.Errorf("whitelist list failed: %w", err)
+	}
+
+	// "There are 2 whitelisted player(s): Alice, Bob", or "There are no
+	// whitelisted players"
+	allowlist := []string{}
+
+	if _, names, found := strings.Cut(response, ":"); found {
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				allowlist = append(allowlist, name)
+			}
+		}
+	}
+
+	return games.PlayerLists{games.Allowlist: allowlist}, nil
+}
+
+func (Driver) AddPlayer(ctx context.Context, console games.Console, list games.PlayerList, name string) error {
+	return playerCommand(ctx, console, playerCommands[list][0], name)
+}
+
+func (Driver) RemovePlayer(ctx context.Context, console games.Console, list games.PlayerList, name string) error {
+	return playerCommand(ctx, console, playerCommands[list][1], name)
+}
+
+func (Driver) PlayerFiles(lists games.PlayerLists) map[string]string {
+	files := make(map[string]string, len(lists))
+
+	for list, names := range lists {
+		if file, ok := playerFiles[list]; ok && len(names) > 0 {
+			files[file] = strings.Join(names, "\n") + "\n"
+		}
+	}
+
+	return files
+}
+
+func playerCommand(ctx context.Context, console games.Console, command, name string) error {
+	if command == "" {
+		return fmt.Errorf("unknown player list")
+	}
+
+	if !playerName.MatchString(name) {
+		return fmt.Errorf("invalid player name %q", name)
+	}
+
+	response, err := console.Command(ctx, command+" "+name)
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", command, err)
+	}
+
+	// players unknown to Mojang can't be added to any list
+	if strings.Contains(response, "does not exist") {
+		return fmt.Errorf("%s %s failed: %s", command, name, strings.TrimSpace(response))
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package games
+
+import "context"
+
+// PlayerList names one of the player lists of a game.
+type PlayerList string
+
+const (
+	Allowlist PlayerList = "allowlist"
+	Ops       PlayerList = "ops"
+	Bans      PlayerList = "bans"
+)
+
+// PlayerListNames are the player lists, in the order they are synced.
+var PlayerListNames = []PlayerList{Allowlist, Ops, Bans}
+
+// PlayerLists holds player names by list.
+type PlayerLists map[PlayerList][]string
+
+// Players is implemented by drivers that manage who may join their game.
+type Players interface {
+	// ListPlayers reads the player lists of the running game. Lists its
+	// console can't show are left out.
+	ListPlayers(ctx context.Context, console Console) (PlayerLists, error)
+
+	// AddPlayer puts a player on a list.
+	AddPlayer(ctx context.Context, console Console, list PlayerList, name string) error
+
+	// RemovePlayer takes a player off a list.
+	RemovePlayer(ctx context.Context, console Console, list PlayerList, name string) error
+
+	// PlayerFiles renders the lists into the files the game seeds them from
+	// on first boot, keyed by file name.
+	PlayerFiles(lists PlayerLists) map[string]string
+}
@@ -0,0 +1,151 @@
+// Package source is the driver of Source engine and other Steam games, which
+// answer the A2S query protocol.
+package source
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+	"github.com/Sackbuoy/gameserver-operator/internal/games"
+	"github.com/Sackbuoy/gameserver-operator/internal/query"
+)
+
+// ConfigFile is where settings of Steam games are rendered to as console
+// variables, games that read another file map it in through their chart.
+const ConfigFile = "server.cfg"
+
+// drivers are the Steam games the operator knows, with their default A2S
+// query ports, which are not always the ports players connect to.
+var drivers = map[string]Driver{
+	"source": {Port: 27015, RCONPort: 27015, RestartSettings: []string{"maxplayers", "hostport"}},
+	"cs2":    {Port: 27015, RCONPort: 27015, RestartSettings: []string{"maxplayers", "hostport"}},
+	"rust": {Port: 28015, RCONPort: 28016, RestartSettings: []string{
+		"server.level", "server.seed", "server.worldsize", "server.port", "server.queryport", "rcon.port",
+	}},
+	"valheim": {Port: 2457},
+}
+
+func init() {
+	for gameType, driver := range drivers {
+		games.Register(gameType, driver)
+	}
+}
+
+// Driver queries Steam games over A2S. Their console commands differ per
+// game, so it leaves stopping and backups to the pod's termination.
+type Driver struct {
+	// Port is the A2S query port
+	Port int32
+	// RCONPort is the RCON port, 0 for games without RCON
+	RCONPort int32
+	// RestartSettings are the console variables read only at startup, every
+	// one is for games without RCON
+	RestartSettings []string
+}
+
+func (d Driver) QueryPort() games.Port {
+	return games.Port{Protocol: "UDP", Number: d.Port, Name: "query"}
+}
+
+func (d Driver) ConsolePort() (games.Port, bool) {
+	return games.Port{Protocol: "TCP", Number: d.RCONPort, Name: "rcon"}, d.RCONPort != 0
+}
+
+func (Driver) Probe(ctx context.Context, address string) (*crds.GameStatus, error) {
+	info, err := query.QueryA2SInfo(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	game := &crds.GameStatus{
+		Name:          info.Name,
+		Map:           info.Map,
+		Version:       info.Version,
+		OnlinePlayers: int32(info.Players),
+		MaxPlayers:    int32(info.MaxPlayers),
+	}
+
+	// the player list is a nicety, the server answered A2S_INFO so it is up
+	players, err := query.QueryA2SPlayers(ctx, address)
+	if err != nil {
+		return game, nil
+	}
+
+	for _, player := range players {
+		// players still connecting have no name yet
+		if player.Name != "" {
+			game.Players = append(game.Players, player.Name)
+		}
+	}
+
+	return game, nil
+}
+
+func (Driver) Broadcast(ctx context.Context, console games.Console, message string) error {
+	_, err := console.Command(ctx, "say "+message)
+
+	return err
+}
+
+func (Driver) PrepareBackup(context.Context, games.Console) (func(context.Context) error, error) {
+	return func(context.Context) error { return nil }, nil
+}
+
+// RenderConfig renders settings as console variables, one per line, the way
+// the game executes them from its config file.
+func (Driver) RenderConfig(settings map[string]any) (map[string]string, error) {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var rendered strings.Builder
+
+	for _, key := range keys {
+		line, err := cvar(key, settings[key])
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", ConfigFile, err)
+		}
+
+		rendered.WriteString(line + "\n")
+	}
+
+	return map[string]string{ConfigFile: rendered.String()}, nil
+}
+
+func (d Driver) RestartRequired(key string) bool {
+	return d.RCONPort == 0 || slices.Contains(d.RestartSettings, key)
+}
+
+// ApplySetting sets a console variable.
+func (Driver) ApplySetting(ctx context.Context, console games.Console, key string, value any) error {
+	command, err := cvar(key, value)
+	if err != nil {
+		return err
+	}
+
+	_, err = console.Command(ctx, command)
+
+	return err
+}
+
+// cvar spells out setting a console variable. The console has no way to
+// escape quotes or line breaks in values.
+func cvar(key string, value any) (string, error) {
+	formatted, err := games.FormatValue(value)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", key, err)
+	}
+
+	if key == "" || strings.ContainsAny(key, " \t\r\n\";") || strings.ContainsAny(formatted, "\"\r\n") {
+		return "", fmt.Errorf("%s: can't be set as a console variable", key)
+	}
+
+	return fmt.Sprintf("%s \"%s\"", key, formatted), nil
+}
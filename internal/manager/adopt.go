@@ -0,0 +1,152 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+const (
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedByValue = "gameserver-operator"
+
+	// ownerUIDLabel, ownerNamespaceLabel and ownerNameLabel identify the
+	// GameServer a release is deployed for
+	ownerUIDLabel       = "goopy.us/owner-uid"
+	ownerNamespaceLabel = "goopy.us/owner-namespace"
+	ownerNameLabel      = "goopy.us/owner-name"
+
+	// deletionPolicyLabel records the deletion policy of the owner, for
+	// garbage collection to follow once the owner is gone
+	deletionPolicyLabel = "goopy.us/deletion-policy"
+)
+
+// ConditionAdopted is True once a release installed outside the operator has
+// been taken over through spec.adoptExisting.
+const ConditionAdopted = "Adopted"
+
+// releaseLabels are the labels every release the operator deploys for a
+// GameServer carries.
+func releaseLabels(gameServer *crds.GameServer) map[string]string {
+	return map[string]string{
+		managedByLabel:      managedByValue,
+		ownerUIDLabel:       string(gameServer.UID),
+		ownerNamespaceLabel: gameServer.Namespace,
+		ownerNameLabel:      gameServer.Name,
+		deletionPolicyLabel: deletionPolicy(gameServer),
+	}
+}
+
+// isManaged reports whether a release is deployed by the operator for a
+// GameServer: its owner labels have to name this very GameServer. Releases
+// deployed before they were labeled are recognized by the GameServer's
+// status.
+func isManaged(rel *release.Release, gameServer *crds.GameServer) bool {
+	if rel.Labels[managedByLabel] == managedByValue && rel.Labels[ownerUIDLabel] != "" {
+		return ownerCollision(rel, gameServer) == nil
+	}
+
+	return gameServer.Status.HelmRelease != nil && gameServer.Status.HelmRelease.Name == rel.Name
+}
+
+// ownerCollision returns an error when a release is deployed by the operator
+// for another GameServer, such as one deleted and recreated under the same
+// name while the operator was down. Such releases are neither adopted nor
+// upgraded.
+func ownerCollision(rel *release.Release, gameServer *crds.GameServer) error {
+	if rel.Labels[managedByLabel] != managedByValue || rel.Labels[ownerUIDLabel] == "" {
+		return nil
+	}
+
+	labels := releaseLabels(gameServer)
+
+	for _, label := range []string{ownerUIDLabel, ownerNamespaceLabel, ownerNameLabel} {
+		if rel.Labels[label] != labels[label] {
+			return fmt.Errorf("release %s is deployed for GameServer %s/%s (uid %s)", rel.Name,
+				rel.Labels[ownerNamespaceLabel], rel.Labels[ownerNameLabel], rel.Labels[ownerUIDLabel])
+		}
+	}
+
+	return nil
+}
+
+// refuseCollision marks a GameServer as failed because its release belongs
+// to another GameServer.
+func (m *Manager) refuseCollision(ctx context.Context, gameServer *crds.GameServer, err error) error {
+	m.logger.Error("Refusing to take over release of another GameServer", zap.String("Name", gameServer.Name), zap.Error(err))
+	m.recordFailure(ctx, gameServer, "ReleaseCollision", err)
+
+	return err
+}
+
+// adopt takes over a release installed outside the operator. The release is
+// labeled as managed and recorded as deployed for the current generation, so
+// the operator upgrades it from the next change on.
+func (m *Manager) adopt(ctx context.Context, actionConfig *action.Configuration, gameServer *crds.GameServer, g *game, resolved *resolvedValues, live *release.Release) error {
+	if !gameServer.Spec.AdoptExisting {
+		err := fmt.Errorf("release %s exists and is not managed by the operator, set spec.adoptExisting to adopt it", live.Name)
+		m.logger.Error("Refusing to take over release", zap.String("ReleaseName", live.Name), zap.Error(err))
+		m.recordFailure(ctx, gameServer, "ReleaseNotManaged", err)
+
+		return err
+	}
+
+	if live.Chart == nil || live.Chart.Name() != g.chart.Name() {
+		liveChart := "unknown"
+		if live.Chart != nil {
+			liveChart = live.Chart.Name()
+		}
+
+		err := fmt.Errorf("release %s deploys chart %s, not %s", live.Name, liveChart, g.chart.Name())
+		m.logger.Error("Failed to adopt release", zap.String("ReleaseName", live.Name), zap.Error(err))
+		m.recordFailure(ctx, gameServer, "AdoptionFailed", err)
+
+		return err
+	}
+
+	if live.Labels == nil {
+		live.Labels = make(map[string]string)
+	}
+
+	for key, value := range releaseLabels(gameServer) {
+		live.Labels[key] = value
+	}
+
+	err := actionConfig.Releases.Update(live)
+	if err != nil {
+		m.logger.Error("Failed to label adopted release", zap.String("ReleaseName", live.Name), zap.Error(err))
+		m.recordFailure(ctx, gameServer, "AdoptionFailed", err)
+
+		return err
+	}
+
+	m.logger.Info("Adopted release",
+		zap.String("ReleaseName", live.Name),
+		zap.Int("Revision", live.Version))
+
+	message := fmt.Sprintf("adopted release %s revision %d (%s-%s)", live.Name, live.Version, live.Chart.Name(), live.Chart.Metadata.Version)
+
+	return m.updateStatus(ctx, gameServer.Namespace, gameServer.Name, func(status *crds.GameServerStatus) {
+		lastDeployed := metav1.NewTime(live.Info.LastDeployed.Time)
+
+		status.Phase = PhaseRunning
+		status.Message = message
+		setCondition(status, ConditionAdopted, ConditionTrue, "AdoptedExisting", message)
+		setCondition(status, ConditionReleased, ConditionTrue, "Adopted", message)
+		status.ObservedGeneration = gameServer.Generation
+		status.Template = gameServer.Status.Template
+		status.HelmRelease = &crds.HelmReleaseStatus{
+			Name:          live.Name,
+			Version:       live.Version,
+			LastDeployed:  &lastDeployed,
+			ValuesHash:    resolved.hash,
+			StorageDriver: m.storageDriver(gameServer),
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	helmtime "helm.sh/helm/v3/pkg/time"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+func TestAdopt(t *testing.T) {
+	survival := &chart.Chart{Metadata: &chart.Metadata{Name: "minecraft-java", Version: "1.0.0"}}
+	other := &chart.Chart{Metadata: &chart.Metadata{Name: "valheim", Version: "1.0.0"}}
+
+	tests := []struct {
+		name       string
+		adopt      bool
+		liveChart  *chart.Chart
+		wantReason string
+	}{
+		{"adopted", true, survival, "Adopted"},
+		{"not asked to", false, survival, "ReleaseNotManaged"},
+		{"other chart", true, other, "AdoptionFailed"},
+		{"no chart", true, nil, "AdoptionFailed"},
+	}
+
+	for _, test := range tests {
+		gameServer := &crds.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "games", Name: "survival", UID: "1234", Generation: 3},
+			Spec:       crds.GameServerSpec{AdoptExisting: test.adopt},
+		}
+
+		m, cluster := newFakeManager(t, gameServerObject(t, gameServer))
+
+		live := &release.Release{
+			Name:      "survival",
+			Namespace: "games",
+			Version:   2,
+			Chart:     test.liveChart,
+			Info:      &release.Info{Status: release.StatusDeployed, LastDeployed: helmtime.Now()},
+		}
+
+		actionConfig := &action.Configuration{Releases: storage.Init(driver.NewMemory())}
+		if err := actionConfig.Releases.Create(live); err != nil {
+			t.Fatalf("%s: store release: %v", test.name, err)
+		}
+
+		resolved := &resolvedValues{hash: "abc"}
+
+		err := m.adopt(context.Background(), actionConfig, gameServer, &game{chart: survival}, resolved, live)
+		if (err == nil) != (test.wantReason == "Adopted") {
+			t.Errorf("%s: adopt = %v", test.name, err)
+		}
+
+		status := cluster.gameServer(t, "games", "survival").Status
+
+		condition := findCondition(status, ConditionReleased)
+		if condition == nil || condition.Reason != test.wantReason {
+			t.Errorf("%s: Released condition = %+v, want reason %s", test.name, condition, test.wantReason)
+		}
+
+		stored, err := actionConfig.Releases.Get("survival", 2)
+		if err != nil {
+			t.Fatalf("%s: get release: %v", test.name, err)
+		}
+
+		if test.wantReason != "Adopted" {
+			if stored.Labels[managedByLabel] != "" {
+				t.Errorf("%s: release labeled %v though not adopted", test.name, stored.Labels)
+			}
+
+			continue
+		}
+
+		if !isManaged(stored, gameServer) {
+			t.Errorf("%s: adopted release labeled %v, want it managed", test.name, stored.Labels)
+		}
+
+		deployed := status.HelmRelease
+		if deployed == nil || deployed.Version != 2 || deployed.ValuesHash != "abc" || status.ObservedGeneration != 3 {
+			t.Errorf("%s: status = %+v, want revision 2 of generation 3 recorded", test.name, status)
+		}
+	}
+}
+
+func TestOwnerCollision(t *testing.T) {
+	gameServer := &crds.GameServer{ObjectMeta: metav1.ObjectMeta{Namespace: "games", Name: "survival", UID: "1234"}}
+
+	owned := releaseLabels(gameServer)
+
+	recreated := releaseLabels(gameServer)
+	recreated[ownerUIDLabel] = "5678"
+
+	moved := releaseLabels(gameServer)
+	moved[ownerNamespaceLabel] = "lobby"
+
+	foreign := map[string]string{managedByLabel: "Helm", ownerUIDLabel: "5678"}
+
+	tests := []struct {
+		name          string
+		labels        map[string]string
+		status        *crds.HelmReleaseStatus
+		wantCollision bool
+		wantManaged   bool
+	}{
+		{name: "owned", labels: owned, wantManaged: true},
+		{name: "recreated owner", labels: recreated, wantCollision: true},
+		{name: "other namespace", labels: moved, wantCollision: true},
+		{name: "installed by hand", labels: foreign},
+		{name: "unlabeled"},
+		{name: "deployed before labeling", status: &crds.HelmReleaseStatus{Name: "survival"}, wantManaged: true},
+		{name: "other release in status", status: &crds.HelmReleaseStatus{Name: "creative"}},
+	}
+
+	for _, test := range tests {
+		rel := &release.Release{Name: "survival", Labels: test.labels}
+
+		owner := *gameServer
+		owner.Status.HelmRelease = test.status
+
+		if err := ownerCollision(rel, &owner); (err != nil) != test.wantCollision {
+			t.Errorf("%s: ownerCollision = %v, want collision %v", test.name, err, test.wantCollision)
+		}
+
+		if got := isManaged(rel, &owner); got != test.wantManaged {
+			t.Errorf("%s: isManaged = %v, want %v", test.name, got, test.wantManaged)
+		}
+	}
+}
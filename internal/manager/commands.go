@@ -0,0 +1,211 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+	"github.com/Sackbuoy/gameserver-operator/internal/rcon"
+)
+
+// Phases of a GameServerCommand.
+const (
+	CommandPhaseRunning   = "Running"
+	CommandPhaseSucceeded = "Succeeded"
+	CommandPhaseFailed    = "Failed"
+)
+
+// defaultCommandTimeout matches the default of spec.timeout.
+const defaultCommandTimeout = 30 * time.Second
+
+// maxCommandResponse is the longest response recorded in status, longer ones
+// keep their tail.
+const maxCommandResponse = 16 * 1024
+
+// RunCommand runs a GameServerCommand that hasn't run yet and records its
+// outcome. Commands run at most once, whatever their outcome. Commands left
+// Running by an operator that stopped while running them are marked Failed
+// as Interrupted, since whether they reached the game is unknown.
+func (m *Manager) RunCommand(ctx context.Context, crdObject map[string]any) error {
+	obj := &unstructured.Unstructured{Object: crdObject}
+
+	var command crds.GameServerCommand
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(crdObject, &command); err != nil {
+		return fmt.Errorf("failed to convert GameServerCommand %s: %w", obj.GetName(), err)
+	}
+
+	// commands this operator runs are Running until they are done
+	key := command.Namespace + "/" + command.Name
+	if _, running := m.commands.LoadOrStore(key, struct{}{}); running {
+		return nil
+	}
+	defer m.commands.Delete(key)
+
+	switch command.Status.Phase {
+	case "":
+	case CommandPhaseRunning:
+		return m.interruptCommand(ctx, obj, &command)
+	default:
+		return nil
+	}
+
+	// claiming the command conflicts when an earlier event already did
+	now := metav1.Now()
+
+	err := m.writeCommandStatus(ctx, obj, crds.GameServerCommandStatus{Phase: CommandPhaseRunning, StartedAt: &now})
+	if apierrors.IsConflict(err) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to claim GameServerCommand %s: %w", command.Name, err)
+	}
+
+	m.logger.Info("Running command",
+		zap.String("GameServerCommand", command.Name),
+		zap.String("GameServer", command.Spec.GameServer))
+
+	response, reason, runErr := m.runCommand(ctx, &command)
+
+	completed := metav1.Now()
+	status := crds.GameServerCommandStatus{
+		Phase:       CommandPhaseSucceeded,
+		Reason:      reason,
+		Response:    tailString(response, maxCommandResponse),
+		StartedAt:   &now,
+		CompletedAt: &completed,
+	}
+
+	if runErr != nil {
+		status.Phase = CommandPhaseFailed
+		status.Message = runErr.Error()
+
+		m.logger.Error("Command failed",
+			zap.String("GameServerCommand", command.Name),
+			zap.String("Reason", reason),
+			zap.Error(runErr))
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest, err := m.k8sClient.Resource(crds.GameServerCommandResource).Namespace(command.Namespace).Get(ctx, command.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		return m.writeCommandStatus(ctx, latest, status)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record outcome of GameServerCommand %s: %w", command.Name, err)
+	}
+
+	return nil
+}
+
+// interruptCommand fails a command another run of the operator left Running.
+func (m *Manager) interruptCommand(ctx context.Context, obj *unstructured.Unstructured, command *crds.GameServerCommand) error {
+	completed := metav1.Now()
+
+	status := command.Status
+	status.Phase = CommandPhaseFailed
+	status.Reason = "Interrupted"
+	status.Message = "the operator stopped while running the command, it may or may not have reached the game"
+	status.CompletedAt = &completed
+
+	// a conflict means the command moved on since it was listed
+	err := m.writeCommandStatus(ctx, obj, status)
+	if apierrors.IsConflict(err) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to record interruption of GameServerCommand %s: %w", command.Name, err)
+	}
+
+	m.logger.Warn("Command interrupted",
+		zap.String("GameServerCommand", command.Name),
+		zap.String("GameServer", command.Spec.GameServer))
+
+	return nil
+}
+
+// runCommand runs a command on the console of its GameServer, returning the
+// response and the reason for the command's final phase.
+func (m *Manager) runCommand(ctx context.Context, command *crds.GameServerCommand) (string, string, error) {
+	timeout := defaultCommandTimeout
+	if command.Spec.Timeout > 0 {
+		timeout = time.Duration(command.Spec.Timeout) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	gameServer, spec, err := m.getGameServer(ctx, command.Namespace, command.Spec.GameServer)
+	if apierrors.IsNotFound(err) {
+		return "", "GameServerNotFound", fmt.Errorf("GameServer %s not found", command.Spec.GameServer)
+	}
+
+	if err != nil {
+		return "", "GameServerNotFound", err
+	}
+
+	// the game type may come from the template
+	if err := m.applyTemplate(ctx, gameServer, spec); err != nil {
+		return "", "TemplateFailed", err
+	}
+
+	console, err := m.console(ctx, gameServer)
+	if err != nil {
+		return "", consoleFailureReason(err), err
+	}
+	defer console.Close()
+
+	response, err := console.Command(ctx, command.Spec.Command)
+	if err != nil {
+		return response, consoleFailureReason(err), err
+	}
+
+	m.recordEvent(ctx, gameServer, EventTypeNormal, "CommandRun",
+		fmt.Sprintf("GameServerCommand %s ran %q", command.Name, command.Spec.Command))
+
+	return response, "Completed", nil
+}
+
+func consoleFailureReason(err error) string {
+	var netErr interface{ Timeout() bool }
+
+	switch {
+	case errors.Is(err, errNoConsole):
+		return "NoConsole"
+	case errors.Is(err, rcon.ErrAuthFailed):
+		return "AuthFailed"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "Timeout"
+	default:
+		return "ConsoleFailed"
+	}
+}
+
+// writeCommandStatus replaces the status of obj, failing with a conflict when
+// obj is outdated.
+func (m *Manager) writeCommandStatus(ctx context.Context, obj *unstructured.Unstructured, status crds.GameServerCommandStatus) error {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&status)
+	if err != nil {
+		return err
+	}
+
+	obj = obj.DeepCopy()
+	obj.Object["status"] = content
+
+	_, err = m.k8sClient.Resource(crds.GameServerCommandResource).Namespace(obj.GetNamespace()).UpdateStatus(ctx, obj, metav1.UpdateOptions{})
+
+	return err
+}
@@ -0,0 +1,67 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+func TestRunCommandInterrupted(t *testing.T) {
+	started := metav1.Now()
+
+	tests := []struct {
+		name       string
+		phase      string
+		running    bool
+		wantPhase  string
+		wantReason string
+	}{
+		{name: "left running", phase: CommandPhaseRunning, wantPhase: CommandPhaseFailed, wantReason: "Interrupted"},
+		{name: "running here", phase: CommandPhaseRunning, running: true, wantPhase: CommandPhaseRunning},
+		{name: "succeeded", phase: CommandPhaseSucceeded, wantPhase: CommandPhaseSucceeded},
+		{name: "failed", phase: CommandPhaseFailed, wantPhase: CommandPhaseFailed},
+	}
+
+	for _, test := range tests {
+		command := &crds.GameServerCommand{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "games", Name: "say-hello"},
+			Spec:       crds.GameServerCommandSpec{GameServer: "survival", Command: "say hello"},
+			Status:     crds.GameServerCommandStatus{Phase: test.phase, StartedAt: &started},
+		}
+
+		fields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(command)
+		if err != nil {
+			t.Fatalf("convert GameServerCommand: %v", err)
+		}
+
+		object := testObject("goopy.us/v1", "GameServerCommand", "games", "say-hello", fields)
+
+		m, cluster := newFakeManager(t, object)
+		if test.running {
+			m.commands.Store("games/say-hello", struct{}{})
+		}
+
+		if err := m.RunCommand(context.Background(), object.Object); err != nil {
+			t.Errorf("%s: RunCommand: %v", test.name, err)
+		}
+
+		var got crds.GameServerCommand
+
+		stored := cluster.get("/apis/goopy.us/v1/namespaces/games/gameservercommands/say-hello")
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(stored.Object, &got); err != nil {
+			t.Fatalf("convert GameServerCommand: %v", err)
+		}
+
+		if got.Status.Phase != test.wantPhase || got.Status.Reason != test.wantReason {
+			t.Errorf("%s: status = %s/%s, want %s/%s", test.name, got.Status.Phase, got.Status.Reason, test.wantPhase, test.wantReason)
+		}
+
+		if test.wantReason == "Interrupted" && (got.Status.CompletedAt == nil || got.Status.StartedAt == nil) {
+			t.Errorf("%s: interrupted command lacks its start or completion time", test.name)
+		}
+	}
+}
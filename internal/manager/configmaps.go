@@ -0,0 +1,178 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+// ConditionConfigMapConflict is True while a ConfigMap the operator writes
+// for a GameServer already exists and belongs to someone else.
+const ConditionConfigMapConflict = "ConfigMapConflict"
+
+// applyConfigMap creates or replaces a ConfigMap next to a GameServer. An owned
+// ConfigMap is garbage collected along with the GameServer; ConfigMaps the
+// release mounts aren't owned, so they outlive an orphaned release, and
+// Delete removes them. Existing ConfigMaps the operator didn't create for
+// this GameServer are never replaced.
+func (m *Manager) applyConfigMap(ctx context.Context, gameServer *crds.GameServer, name string, data map[string]string, owned bool) error {
+	client := m.k8sClient.Resource(configMapResource).Namespace(gameServer.Namespace)
+
+	configMapData := make(map[string]any, len(data))
+	for key, value := range data {
+		configMapData[key] = value
+	}
+
+	configMap := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]any{
+			"name":      name,
+			"namespace": gameServer.Namespace,
+			"labels": map[string]any{
+				managedByLabel:        managedByValue,
+				"goopy.us/gameserver": gameServer.Name,
+			},
+		},
+		"data": configMapData,
+	}}
+
+	if owned {
+		configMap.SetOwnerReferences([]metav1.OwnerReference{gameServerOwnerReference(gameServer)})
+	}
+
+	existing, err := client.Get(ctx, name, metav1.GetOptions{})
+
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = client.Create(ctx, configMap, metav1.CreateOptions{})
+	case err != nil:
+		return err
+	case !managedConfigMap(existing, gameServer):
+		return m.refuseConfigMap(ctx, gameServer, name)
+	default:
+		configMap.SetResourceVersion(existing.GetResourceVersion())
+
+		_, err = client.Update(ctx, configMap, metav1.UpdateOptions{})
+	}
+
+	if err != nil {
+		return err
+	}
+
+	m.clearConfigMapConflict(ctx, gameServer, name)
+
+	return nil
+}
+
+// managedConfigMap reports whether a ConfigMap was created by the operator for
+// a GameServer: it carries the operator's labels naming the GameServer, or an
+// owner reference to it.
+func managedConfigMap(configMap *unstructured.Unstructured, gameServer *crds.GameServer) bool {
+	labels := configMap.GetLabels()
+	if labels[managedByLabel] == managedByValue && labels["goopy.us/gameserver"] == gameServer.Name {
+		return true
+	}
+
+	for _, owner := range configMap.GetOwnerReferences() {
+		if gameServer.UID != "" && owner.UID == gameServer.UID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func configMapConflictMessage(name string) string {
+	return fmt.Sprintf("ConfigMap %s exists and is not managed by the operator", name)
+}
+
+// refuseConfigMap reports a ConfigMap the operator would overwrite.
+func (m *Manager) refuseConfigMap(ctx context.Context, gameServer *crds.GameServer, name string) error {
+	message := configMapConflictMessage(name)
+
+	err := m.updateStatus(ctx, gameServer.Namespace, gameServer.Name, func(status *crds.GameServerStatus) {
+		setCondition(status, ConditionConfigMapConflict, ConditionTrue, "NotManaged", message)
+	})
+	if err != nil {
+		m.logger.Error("Failed to update GameServer status", zap.String("Name", gameServer.Name), zap.Error(err))
+	}
+
+	return fmt.Errorf("refusing to replace %s/%s: %s", gameServer.Namespace, name, message)
+}
+
+// clearConfigMapConflict drops the conflict reported for a ConfigMap once the
+// operator could write it.
+func (m *Manager) clearConfigMapConflict(ctx context.Context, gameServer *crds.GameServer, name string) {
+	conflict := func(status *crds.GameServerStatus) bool {
+		for _, condition := range status.Conditions {
+			if condition.Type == ConditionConfigMapConflict && condition.Message == configMapConflictMessage(name) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if !conflict(&gameServer.Status) {
+		return
+	}
+
+	err := m.updateStatus(ctx, gameServer.Namespace, gameServer.Name, func(status *crds.GameServerStatus) {
+		if conflict(status) {
+			removeCondition(status, ConditionConfigMapConflict)
+		}
+	})
+	if err != nil {
+		m.logger.Error("Failed to update GameServer status", zap.String("Name", gameServer.Name), zap.Error(err))
+	}
+}
+
+// deleteMountedConfigMaps removes the ConfigMaps the release of a GameServer
+// mounts.
+func (m *Manager) deleteMountedConfigMaps(ctx context.Context, gameServer *crds.GameServer) error {
+	return m.deleteConfigMaps(ctx, gameServer, gameConfigMapName(gameServer), playersConfigMapName(gameServer))
+}
+
+// deleteConfigMaps removes ConfigMaps the operator created for a GameServer.
+// ConfigMaps the operator didn't create are left alone.
+func (m *Manager) deleteConfigMaps(ctx context.Context, gameServer *crds.GameServer, names ...string) error {
+	client := m.k8sClient.Resource(configMapResource).Namespace(gameServer.Namespace)
+
+	for _, name := range names {
+		configMap, err := client.Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !managedConfigMap(configMap, gameServer) {
+			continue
+		}
+
+		err = client.Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func gameServerOwnerReference(gameServer *crds.GameServer) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: crds.GameServerResource.GroupVersion().String(),
+		Kind:       "GameServer",
+		Name:       gameServer.Name,
+		UID:        gameServer.UID,
+	}
+}
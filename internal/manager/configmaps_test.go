@@ -0,0 +1,93 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+func TestApplyConfigMap(t *testing.T) {
+	gameServer := &crds.GameServer{ObjectMeta: metav1.ObjectMeta{Namespace: "games", Name: "survival", UID: "1234"}}
+
+	existing := func(labels map[string]string, owners ...metav1.OwnerReference) *unstructured.Unstructured {
+		obj := testObject("v1", "ConfigMap", "games", "survival-config", map[string]any{
+			"data": map[string]any{"server.properties": "motd=theirs"},
+		})
+		obj.SetLabels(labels)
+		obj.SetOwnerReferences(owners)
+
+		return obj
+	}
+
+	tests := []struct {
+		name     string
+		existing *unstructured.Unstructured
+		refused  bool
+	}{
+		{name: "created"},
+		{name: "managed", existing: existing(map[string]string{managedByLabel: managedByValue, "goopy.us/gameserver": "survival"})},
+		{name: "owned", existing: existing(nil, metav1.OwnerReference{Name: "survival", UID: "1234"})},
+		{name: "someone else's", existing: existing(map[string]string{"app": "survival"}), refused: true},
+		{name: "other GameServer's", existing: existing(map[string]string{managedByLabel: managedByValue, "goopy.us/gameserver": "creative"}), refused: true},
+		{name: "recreated owner", existing: existing(nil, metav1.OwnerReference{Name: "survival", UID: "5678"}), refused: true},
+	}
+
+	for _, test := range tests {
+		objects := []*unstructured.Unstructured{gameServerObject(t, gameServer)}
+		if test.existing != nil {
+			objects = append(objects, test.existing)
+		}
+
+		m, cluster := newFakeManager(t, objects...)
+
+		err := m.applyConfigMap(context.Background(), gameServer, "survival-config", map[string]string{"server.properties": "motd=ours"}, false)
+		if (err != nil) != test.refused {
+			t.Errorf("%s: applyConfigMap = %v, want refused %v", test.name, err, test.refused)
+		}
+
+		configMap := cluster.get("/api/v1/namespaces/games/configmaps/survival-config")
+		data, _, _ := unstructured.NestedString(configMap.Object, "data", "server.properties")
+
+		want := "motd=ours"
+		if test.refused {
+			want = "motd=theirs"
+		}
+
+		if data != want {
+			t.Errorf("%s: server.properties = %q, want %q", test.name, data, want)
+		}
+
+		conflict := findCondition(cluster.gameServer(t, "games", "survival").Status, ConditionConfigMapConflict)
+		if (conflict != nil) != test.refused {
+			t.Errorf("%s: ConfigMapConflict condition = %+v, want it set %v", test.name, conflict, test.refused)
+		}
+	}
+}
+
+func TestApplyConfigMapClearsConflict(t *testing.T) {
+	gameServer := &crds.GameServer{ObjectMeta: metav1.ObjectMeta{Namespace: "games", Name: "survival", UID: "1234"}}
+	setCondition(&gameServer.Status, ConditionConfigMapConflict, ConditionTrue, "NotManaged", configMapConflictMessage("survival-players"))
+
+	m, cluster := newFakeManager(t, gameServerObject(t, gameServer))
+
+	// another ConfigMap still conflicts
+	if err := m.applyConfigMap(context.Background(), gameServer, "survival-config", nil, false); err != nil {
+		t.Fatalf("applyConfigMap: %v", err)
+	}
+
+	if findCondition(cluster.gameServer(t, "games", "survival").Status, ConditionConfigMapConflict) == nil {
+		t.Error("conflict of survival-players cleared by writing survival-config")
+	}
+
+	if err := m.applyConfigMap(context.Background(), gameServer, "survival-players", nil, false); err != nil {
+		t.Fatalf("applyConfigMap: %v", err)
+	}
+
+	if condition := findCondition(cluster.gameServer(t, "games", "survival").Status, ConditionConfigMapConflict); condition != nil {
+		t.Errorf("ConfigMapConflict = %+v, want it cleared once survival-players is written", condition)
+	}
+}
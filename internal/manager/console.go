@@ -0,0 +1,77 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+	"github.com/Sackbuoy/gameserver-operator/internal/games"
+	"github.com/Sackbuoy/gameserver-operator/internal/rcon"
+)
+
+// rconPasswordKey is the key of the RCON password in its Secret.
+const rconPasswordKey = "password"
+
+// errNoConsole is returned for games whose driver knows no console.
+var errNoConsole = errors.New("the game has no RCON console")
+
+// rconSecretName is the Secret the RCON password of a GameServer is kept in.
+func rconSecretName(gameServer *crds.GameServer) string {
+	if gameServer.Spec.RCON != nil && gameServer.Spec.RCON.PasswordSecret != "" {
+		return gameServer.Spec.RCON.PasswordSecret
+	}
+
+	return gameServer.Name + "-rcon"
+}
+
+// consolePort returns the RCON port of a GameServer's game.
+func consolePort(gameServer *crds.GameServer) (games.Port, error) {
+	driver := games.Lookup(gameServer.Spec.GameType)
+	if driver == nil {
+		return games.Port{}, errNoConsole
+	}
+
+	port, ok := driver.ConsolePort()
+	if !ok {
+		return games.Port{}, errNoConsole
+	}
+
+	if gameServer.Spec.RCON != nil && gameServer.Spec.RCON.Port != 0 {
+		port = games.Port{Protocol: "TCP", Number: gameServer.Spec.RCON.Port}
+	}
+
+	return port, nil
+}
+
+// console connects to the RCON console of a GameServer through the Services
+// of its release. The caller closes it.
+func (m *Manager) console(ctx context.Context, gameServer *crds.GameServer) (*rcon.Client, error) {
+	port, err := consolePort(gameServer)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := serviceAddress(gameServer.Namespace, gameServer.Status.Networking, port, false)
+	if err != nil {
+		return nil, fmt.Errorf("no RCON port: %w", err)
+	}
+
+	secretName := rconSecretName(gameServer)
+
+	password, found, err := m.readValuesReference(ctx, gameServer.Namespace, "Secret", secretName, rconPasswordKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, fmt.Errorf("secret %s/%s has no %s", gameServer.Namespace, secretName, rconPasswordKey)
+	}
+
+	client, err := rcon.Dial(ctx, address, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RCON at %s: %w", address, err)
+	}
+
+	return client, nil
+}
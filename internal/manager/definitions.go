@@ -0,0 +1,252 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+// game is what a GameServer's gameType resolves to.
+type game struct {
+	chart *chart.Chart
+
+	// definition is nil for game types deployed straight from a bundled
+	// chart, without a GameDefinition
+	definition *crds.GameDefinition
+}
+
+// loadGame resolves the gameType of a GameServer through its GameDefinition,
+// falling back to the chart bundled under /charts/<gameType>.
+func (m *Manager) loadGame(ctx context.Context, gameServer *crds.GameServer) (*game, error) {
+	definition, err := m.getGameDefinition(ctx, gameServer.Spec.GameType)
+	if err != nil {
+		return nil, err
+	}
+
+	if definition == nil {
+		chrt, err := loadChart(gameServer.Spec.GameType)
+		if err != nil {
+			return nil, err
+		}
+
+		return &game{chart: chrt}, nil
+	}
+
+	chrt, err := m.loadDefinitionChart(definition, gameServer.Spec.HelmChart.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	return &game{chart: chrt, definition: definition}, nil
+}
+
+// getGameDefinition returns the GameDefinition of a gameType, or nil when
+// there is none.
+func (m *Manager) getGameDefinition(ctx context.Context, gameType string) (*crds.GameDefinition, error) {
+	obj, err := m.k8sClient.Resource(crds.GameDefinitionResource).Get(ctx, gameType, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GameDefinition %s: %w", gameType, err)
+	}
+
+	var definition crds.GameDefinition
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &definition); err != nil {
+		return nil, fmt.Errorf("failed to convert GameDefinition %s: %w", gameType, err)
+	}
+
+	return &definition, nil
+}
+
+// loadDefinitionChart loads the chart of a GameDefinition, at the version a
+// GameServer asks for when it is within the allowed range.
+func (m *Manager) loadDefinitionChart(definition *crds.GameDefinition, version string) (*chart.Chart, error) {
+	source := definition.Spec.Chart
+
+	var constraint *semver.Constraints
+
+	if source.VersionRange != "" {
+		var err error
+
+		constraint, err = semver.NewConstraint(source.VersionRange)
+		if err != nil {
+			return nil, fmt.Errorf("GameDefinition %s has an invalid versionRange: %w", definition.Name, err)
+		}
+	}
+
+	if version != "" && constraint != nil {
+		requested, err := semver.NewVersion(version)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chart version %q: %w", version, err)
+		}
+
+		if !constraint.Check(requested) {
+			return nil, fmt.Errorf("chart version %s is outside the range %s allowed by GameDefinition %s",
+				version, source.VersionRange, definition.Name)
+		}
+	}
+
+	var (
+		chrt *chart.Chart
+		err  error
+	)
+
+	switch {
+	case source.Path != "":
+		chrt, err = loader.Load(source.Path)
+	case source.Repository != "" && source.Name != "":
+		if version == "" {
+			version = source.VersionRange
+		}
+
+		chrt, err = m.loadRepositoryChart(source.Repository, source.Name, version)
+	default:
+		return nil, fmt.Errorf("GameDefinition %s has no chart path or repository", definition.Name)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart of GameDefinition %s: %w", definition.Name, err)
+	}
+
+	if constraint != nil {
+		loaded, err := semver.NewVersion(chrt.Metadata.Version)
+		if err != nil || !constraint.Check(loaded) {
+			return nil, fmt.Errorf("chart %s %s is outside the range %s allowed by GameDefinition %s",
+				chrt.Name(), chrt.Metadata.Version, source.VersionRange, definition.Name)
+		}
+	}
+
+	return chrt, nil
+}
+
+// cachedChart is a chart downloaded from a repository.
+type cachedChart struct {
+	chart    *chart.Chart
+	loadedAt time.Time
+}
+
+// loadRepositoryChart downloads a chart from a repository. version may be a
+// semver range, in which case the newest matching version is picked. Charts
+// are cached for Options.ChartCacheTTL, so that ranges pick up new releases
+// and re-pushed versions are fetched again; a stale chart is used while the
+// repository can't be reached.
+func (m *Manager) loadRepositoryChart(repository, name, version string) (*chart.Chart, error) {
+	key := fmt.Sprintf("%s|%s|%s", repository, name, version)
+
+	var stale *cachedChart
+
+	if value, ok := m.chartCache.Load(key); ok {
+		cached, _ := value.(*cachedChart)
+		if time.Since(cached.loadedAt) < m.options.ChartCacheTTL {
+			return cached.chart, nil
+		}
+
+		stale = cached
+	}
+
+	chrt, err := m.downloadChart(repository, name, version)
+	if err != nil {
+		if stale == nil {
+			return nil, err
+		}
+
+		m.logger.Error("Failed to refresh chart, using the cached one",
+			zap.String("Repository", repository),
+			zap.String("Chart", name),
+			zap.String("Version", stale.chart.Metadata.Version),
+			zap.Error(err))
+
+		return stale.chart, nil
+	}
+
+	m.chartCache.Store(key, &cachedChart{chart: chrt, loadedAt: time.Now()})
+
+	return chrt, nil
+}
+
+func (m *Manager) downloadChart(repository, name, version string) (*chart.Chart, error) {
+	pathOptions := action.ChartPathOptions{
+		RepoURL: repository,
+		Version: version,
+	}
+
+	path, err := pathOptions.LocateChart(name, m.helmSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	return loader.Load(path)
+}
+
+// effectiveSpec fills in the parts of a GameServer spec left to its
+// GameDefinition, and checks the spec meets the definition's requirements.
+func (g *game) effectiveSpec(spec crds.GameServerSpec) (crds.GameServerSpec, error) {
+	if g.definition == nil {
+		return spec, nil
+	}
+
+	definition := g.definition.Spec
+
+	if len(definition.Ports) > 0 && (spec.Networking == nil || len(spec.Networking.Ports) == 0) {
+		networking := crds.NetworkingConfig{}
+		if spec.Networking != nil {
+			networking = *spec.Networking
+		}
+
+		networking.Ports = definition.Ports
+		spec.Networking = &networking
+	}
+
+	// persistence left unset is up to the chart and the values defaults,
+	// unless the game requires it
+	if definition.Persistence != nil {
+		required := definition.Persistence.Required
+
+		if required && spec.Persistence != nil && !spec.Persistence.Enabled {
+			return spec, fmt.Errorf("game type %s requires persistence", g.definition.Name)
+		}
+
+		if spec.Persistence != nil || required {
+			persistence := crds.PersistenceConfig{Enabled: true}
+			if spec.Persistence != nil {
+				persistence = *spec.Persistence
+			}
+
+			if persistence.Enabled && persistence.Size == "" {
+				persistence.Size = definition.Persistence.Size
+			}
+
+			spec.Persistence = &persistence
+		}
+	}
+
+	return spec, nil
+}
+
+// valueMapping returns the value mapping of the game, preferring the one
+// declared in its GameDefinition over the one shipped with the chart.
+func (g *game) valueMapping() (valueMapping, error) {
+	if g.definition == nil || len(g.definition.Spec.ValueMapping) == 0 {
+		return chartValueMapping(g.chart)
+	}
+
+	mapping := valueMapping(g.definition.Spec.ValueMapping)
+	if err := mapping.validate(); err != nil {
+		return nil, fmt.Errorf("GameDefinition %s: %w", g.definition.Name, err)
+	}
+
+	return mapping, nil
+}
@@ -0,0 +1,179 @@
+package manager
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/cli"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+func TestEffectiveSpec(t *testing.T) {
+	definitionPorts := []crds.PortConfig{{Name: "game", Port: 25565}}
+
+	tests := []struct {
+		name        string
+		persistence *crds.PersistenceRequirements
+		spec        crds.GameServerSpec
+		want        crds.GameServerSpec
+		fails       bool
+	}{
+		{
+			name:        "unset persistence is left to the chart",
+			persistence: &crds.PersistenceRequirements{Size: "5Gi"},
+			spec:        crds.GameServerSpec{},
+			want:        crds.GameServerSpec{Networking: &crds.NetworkingConfig{Ports: definitionPorts}},
+		},
+		{
+			name:        "required persistence is enabled",
+			persistence: &crds.PersistenceRequirements{Required: true, Size: "5Gi"},
+			spec:        crds.GameServerSpec{},
+			want: crds.GameServerSpec{
+				Networking:  &crds.NetworkingConfig{Ports: definitionPorts},
+				Persistence: &crds.PersistenceConfig{Enabled: true, Size: "5Gi"},
+			},
+		},
+		{
+			name:        "enabled persistence gets the default size",
+			persistence: &crds.PersistenceRequirements{Size: "5Gi"},
+			spec:        crds.GameServerSpec{Persistence: &crds.PersistenceConfig{Enabled: true, StorageClass: "fast"}},
+			want: crds.GameServerSpec{
+				Networking:  &crds.NetworkingConfig{Ports: definitionPorts},
+				Persistence: &crds.PersistenceConfig{Enabled: true, Size: "5Gi", StorageClass: "fast"},
+			},
+		},
+		{
+			name:        "disabled persistence gets no size",
+			persistence: &crds.PersistenceRequirements{Size: "5Gi"},
+			spec:        crds.GameServerSpec{Persistence: &crds.PersistenceConfig{}},
+			want: crds.GameServerSpec{
+				Networking:  &crds.NetworkingConfig{Ports: definitionPorts},
+				Persistence: &crds.PersistenceConfig{},
+			},
+		},
+		{
+			name:        "own ports and size win",
+			persistence: &crds.PersistenceRequirements{Size: "5Gi"},
+			spec: crds.GameServerSpec{
+				Networking:  &crds.NetworkingConfig{Type: "NodePort", Ports: []crds.PortConfig{{Port: 30000}}},
+				Persistence: &crds.PersistenceConfig{Enabled: true, Size: "50Gi"},
+			},
+			want: crds.GameServerSpec{
+				Networking:  &crds.NetworkingConfig{Type: "NodePort", Ports: []crds.PortConfig{{Port: 30000}}},
+				Persistence: &crds.PersistenceConfig{Enabled: true, Size: "50Gi"},
+			},
+		},
+		{
+			name:        "required persistence can't be disabled",
+			persistence: &crds.PersistenceRequirements{Required: true},
+			spec:        crds.GameServerSpec{Persistence: &crds.PersistenceConfig{}},
+			fails:       true,
+		},
+	}
+
+	for _, test := range tests {
+		g := &game{definition: &crds.GameDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "minecraft-java"},
+			Spec:       crds.GameDefinitionSpec{Ports: definitionPorts, Persistence: test.persistence},
+		}}
+
+		spec, err := g.effectiveSpec(test.spec)
+		if test.fails {
+			if err == nil {
+				t.Errorf("%s: effectiveSpec accepted %+v", test.name, test.spec)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: effectiveSpec: %v", test.name, err)
+
+			continue
+		}
+
+		if !reflect.DeepEqual(spec, test.want) {
+			t.Errorf("%s: effectiveSpec = %+v, want %+v", test.name, spec, test.want)
+		}
+	}
+}
+
+func TestLoadDefinitionChartVersionRange(t *testing.T) {
+	// the bundled chart is version 0.1.0
+	tests := []struct {
+		name         string
+		versionRange string
+		version      string
+		path         string
+		loads        bool
+	}{
+		{name: "no range", path: "../../charts/minecraft-java", loads: true},
+		{name: "within range", versionRange: ">=0.1.0 <1.0.0", path: "../../charts/minecraft-java", loads: true},
+		{name: "requested within range", versionRange: "<1.0.0", version: "0.1.0", path: "../../charts/minecraft-java", loads: true},
+		{name: "loaded outside range", versionRange: ">=1.0.0", path: "../../charts/minecraft-java"},
+		{name: "requested outside range", versionRange: "<1.0.0", version: "1.2.0", path: "../../charts/minecraft-java"},
+		{name: "invalid range", versionRange: ">=>1", path: "../../charts/minecraft-java"},
+		{name: "invalid version", versionRange: "<1.0.0", version: "latest", path: "../../charts/minecraft-java"},
+		{name: "no chart", versionRange: "<1.0.0"},
+	}
+
+	m := &Manager{}
+
+	for _, test := range tests {
+		definition := &crds.GameDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "minecraft-java"},
+			Spec: crds.GameDefinitionSpec{Chart: crds.ChartSource{
+				Path:         test.path,
+				VersionRange: test.versionRange,
+			}},
+		}
+
+		chrt, err := m.loadDefinitionChart(definition, test.version)
+		if test.loads && (err != nil || chrt.Name() != "minecraft-java") {
+			t.Errorf("%s: loadDefinitionChart = %v, want the bundled chart", test.name, err)
+		}
+
+		if !test.loads && err == nil {
+			t.Errorf("%s: loadDefinitionChart loaded %s %s", test.name, chrt.Name(), chrt.Metadata.Version)
+		}
+	}
+}
+
+func TestLoadRepositoryChartCache(t *testing.T) {
+	for _, env := range []string{"HELM_CACHE_HOME", "HELM_CONFIG_HOME", "HELM_DATA_HOME"} {
+		t.Setenv(env, t.TempDir())
+	}
+
+	// nothing listens there, so every download fails
+	const repository = "http://127.0.0.1:1"
+
+	m := &Manager{
+		options:      Options{ChartCacheTTL: time.Hour},
+		helmSettings: cli.New(),
+		logger:       zap.NewNop(),
+	}
+
+	fresh := &chart.Chart{Metadata: &chart.Metadata{Name: "fresh", Version: "1.0.0"}}
+	m.chartCache.Store(repository+"|fresh|1.0.0", &cachedChart{chart: fresh, loadedAt: time.Now()})
+
+	stale := &chart.Chart{Metadata: &chart.Metadata{Name: "stale", Version: "1.0.0"}}
+	m.chartCache.Store(repository+"|stale|1.0.0", &cachedChart{chart: stale, loadedAt: time.Now().Add(-2 * time.Hour)})
+
+	if chrt, err := m.loadRepositoryChart(repository, "fresh", "1.0.0"); err != nil || chrt != fresh {
+		t.Errorf("fresh chart = %v, %v, want it from the cache", chrt, err)
+	}
+
+	// expired, and kept while the repository can't be reached
+	if chrt, err := m.loadRepositoryChart(repository, "stale", "1.0.0"); err != nil || chrt != stale {
+		t.Errorf("stale chart = %v, %v, want the cached one after a failed refresh", chrt, err)
+	}
+
+	if _, err := m.loadRepositoryChart(repository, "missing", "1.0.0"); err == nil {
+		t.Error("loaded a chart that was neither cached nor downloaded")
+	}
+}
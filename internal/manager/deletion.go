@@ -0,0 +1,378 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+	"github.com/Sackbuoy/gameserver-operator/internal/games"
+)
+
+// Deletion policies, see crds.GameServerSpec.DeletionPolicy.
+const (
+	// DeletionPolicyDelete uninstalls the release, with whatever the chart
+	// does to its volumes
+	DeletionPolicyDelete = "Delete"
+	// DeletionPolicyRetainData uninstalls the release but keeps its
+	// PersistentVolumeClaims, labeled for reuse
+	DeletionPolicyRetainData = "RetainData"
+	// DeletionPolicySnapshot takes a VolumeSnapshot of every claim of the
+	// release before uninstalling it
+	DeletionPolicySnapshot = "Snapshot"
+	// DeletionPolicyOrphan leaves the release installed and unmanaged
+	DeletionPolicyOrphan = "Orphan"
+)
+
+const (
+	// retainedGameServerLabel and retainedReleaseLabel mark claims kept by
+	// RetainData, and snapshots taken by Snapshot
+	retainedGameServerLabel = "goopy.us/retained-gameserver"
+	retainedReleaseLabel    = "goopy.us/retained-release"
+	retainedAtAnnotation    = "goopy.us/retained-at"
+
+	helmInstanceLabel = "app.kubernetes.io/instance"
+)
+
+const (
+	// snapshotReadyTimeout bounds the wait for a VolumeSnapshot to be cut
+	snapshotReadyTimeout = 10 * time.Minute
+	snapshotPollInterval = 2 * time.Second
+)
+
+var (
+	pvcResource = schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}
+
+	volumeSnapshotResource = schema.GroupVersionResource{
+		Group:    "snapshot.storage.k8s.io",
+		Version:  "v1",
+		Resource: "volumesnapshots",
+	}
+)
+
+// deletionPolicy returns the deletion policy of a GameServer. Data is kept
+// unless deleting it was asked for.
+func deletionPolicy(gameServer *crds.GameServer) string {
+	if gameServer.Spec.DeletionPolicy == "" {
+		return DeletionPolicyRetainData
+	}
+
+	return gameServer.Spec.DeletionPolicy
+}
+
+// uninstall removes the release of the GameServer owner according to
+// policy. Snapshot failures abort the uninstall, so no data is lost without
+// a snapshot of it. gameServer is the owner when it is known; with Snapshot,
+// its game is stopped around the snapshots, the other policies leave
+// stopping it to the caller.
+func (m *Manager) uninstall(ctx context.Context, actionConfig *action.Configuration, releaseName string, owner types.NamespacedName, gameServer *crds.GameServer, policy string) error {
+	rel, err := action.NewGet(actionConfig).Run(releaseName)
+	if err != nil {
+		return err
+	}
+
+	if policy == DeletionPolicyOrphan {
+		return m.orphanRelease(actionConfig, rel)
+	}
+
+	claims, err := m.releaseClaims(ctx, rel)
+	if err != nil {
+		return err
+	}
+
+	switch policy {
+	case DeletionPolicySnapshot:
+		if err := m.snapshotClaims(ctx, rel, owner, gameServer, claims); err != nil {
+			return err
+		}
+	case DeletionPolicyRetainData:
+		// Helm keeps what the stored manifest marks to be kept
+		rel.Manifest, err = keepClaims(rel.Manifest)
+		if err != nil {
+			return err
+		}
+
+		if err := actionConfig.Releases.Update(rel); err != nil {
+			return fmt.Errorf("failed to mark claims of %s to be kept: %w", releaseName, err)
+		}
+	}
+
+	result, err := action.NewUninstall(actionConfig).Run(releaseName)
+	if err != nil {
+		return err
+	}
+
+	if result != nil && result.Info != "" {
+		m.logger.Info(result.Info)
+	}
+
+	if policy == DeletionPolicyRetainData {
+		for _, claim := range claims {
+			if err := m.labelRetainedClaim(ctx, rel, owner, claim); err != nil {
+				m.logger.Error("Failed to label retained claim", zap.String("Claim", claim), zap.Error(err))
+			}
+		}
+	}
+
+	return nil
+}
+
+// orphanRelease drops the operator's labels from a release, so that neither
+// the operator nor its garbage collection touch it again.
+func (m *Manager) orphanRelease(actionConfig *action.Configuration, rel *release.Release) error {
+	for _, label := range []string{managedByLabel, ownerUIDLabel, ownerNamespaceLabel, ownerNameLabel, deletionPolicyLabel} {
+		delete(rel.Labels, label)
+	}
+
+	if err := actionConfig.Releases.Update(rel); err != nil {
+		return fmt.Errorf("failed to orphan release %s: %w", rel.Name, err)
+	}
+
+	m.logger.Info("Orphaned release", zap.String("ReleaseName", rel.Name))
+
+	return nil
+}
+
+// releaseClaims returns the names of the PersistentVolumeClaims of a release,
+// those in its manifest and those labeled with it, such as the claims of
+// StatefulSet volume templates.
+func (m *Manager) releaseClaims(ctx context.Context, rel *release.Release) ([]string, error) {
+	names := make(map[string]struct{})
+
+	for _, document := range releaseutil.SplitManifests(rel.Manifest) {
+		node, err := yaml.Parse(document)
+		if err != nil || node.GetKind() != "PersistentVolumeClaim" {
+			continue
+		}
+
+		names[node.GetName()] = struct{}{}
+	}
+
+	list, err := m.k8sClient.Resource(pvcResource).Namespace(rel.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", helmInstanceLabel, rel.Name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list claims of %s: %w", rel.Name, err)
+	}
+
+	for _, item := range list.Items {
+		names[item.GetName()] = struct{}{}
+	}
+
+	claims := make([]string, 0, len(names))
+	for name := range names {
+		claims = append(claims, name)
+	}
+
+	sort.Strings(claims)
+
+	return claims, nil
+}
+
+// keepClaims marks every PersistentVolumeClaim of a manifest with Helm's keep
+// resource policy.
+func keepClaims(manifest string) (string, error) {
+	documents := releaseutil.SplitManifests(manifest)
+
+	keys := make([]string, 0, len(documents))
+	for key := range documents {
+		keys = append(keys, key)
+	}
+
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+	var kept strings.Builder
+
+	for _, key := range keys {
+		document := documents[key]
+
+		node, err := yaml.Parse(document)
+		if err == nil && node.GetKind() == "PersistentVolumeClaim" {
+			if err := node.PipeE(yaml.SetAnnotation(kube.ResourcePolicyAnno, kube.KeepPolicy)); err != nil {
+				return "", err
+			}
+
+			document, err = node.String()
+			if err != nil {
+				return "", err
+			}
+		}
+
+		kept.WriteString("---\n")
+		kept.WriteString(strings.TrimSpace(document))
+		kept.WriteString("\n")
+	}
+
+	return kept.String(), nil
+}
+
+func (m *Manager) labelRetainedClaim(ctx context.Context, rel *release.Release, owner types.NamespacedName, claim string) error {
+	client := m.k8sClient.Resource(pvcResource).Namespace(rel.Namespace)
+
+	obj, err := client.Get(ctx, claim, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+
+	labels[retainedGameServerLabel] = owner.Name
+	labels[retainedReleaseLabel] = rel.Name
+	obj.SetLabels(labels)
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	annotations[retainedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	obj.SetAnnotations(annotations)
+
+	_, err = client.Update(ctx, obj, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+
+	m.logger.Info("Retained claim", zap.String("Claim", claim), zap.String("ReleaseName", rel.Name))
+
+	return nil
+}
+
+// snapshotClaims snapshots the claims of a release, then stops its game.
+// Once the players have been warned, the game flushes its data to disk and
+// holds off writing while the snapshots are cut. A game that can't be
+// prepared is stopped first instead, which saves it too.
+func (m *Manager) snapshotClaims(ctx context.Context, rel *release.Release, owner types.NamespacedName, gameServer *crds.GameServer, claims []string) error {
+	snapshot := func(ctx context.Context) error {
+		for _, claim := range claims {
+			if err := m.snapshotClaim(ctx, rel, owner, claim); err != nil {
+				return fmt.Errorf("failed to snapshot %s, not uninstalling: %w", claim, err)
+			}
+		}
+
+		return nil
+	}
+
+	if gameServer == nil {
+		return snapshot(ctx)
+	}
+
+	var (
+		taken       bool
+		snapshotErr error
+	)
+
+	m.shutDown(ctx, gameServer, "shutting down", func(ctx context.Context, driver games.Driver, console games.Console) error {
+		resume, err := driver.PrepareBackup(ctx, console)
+		if err != nil {
+			m.logger.Error("Failed to prepare game for snapshots, stopping it first", zap.String("Name", gameServer.Name), zap.Error(err))
+
+			return nil
+		}
+
+		taken = true
+		snapshotErr = snapshot(ctx)
+
+		if err := resume(ctx); err != nil {
+			m.logger.Error("Failed to resume game after snapshots", zap.String("Name", gameServer.Name), zap.Error(err))
+		}
+
+		return snapshotErr
+	})
+
+	if taken {
+		return snapshotErr
+	}
+
+	return snapshot(ctx)
+}
+
+// snapshotClaim takes a VolumeSnapshot of a claim with the default snapshot
+// class, and waits until it is ready to use: the claim can't be deleted
+// before the snapshot has been cut.
+func (m *Manager) snapshotClaim(ctx context.Context, rel *release.Release, owner types.NamespacedName, claim string) error {
+	name := fmt.Sprintf("%s-%s", claim, time.Now().UTC().Format("20060102150405"))
+
+	snapshot := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": volumeSnapshotResource.GroupVersion().String(),
+		"kind":       "VolumeSnapshot",
+		"metadata": map[string]any{
+			"name":      name,
+			"namespace": rel.Namespace,
+			"labels": map[string]any{
+				managedByLabel:          managedByValue,
+				retainedGameServerLabel: owner.Name,
+				retainedReleaseLabel:    rel.Name,
+			},
+		},
+		"spec": map[string]any{
+			"source": map[string]any{
+				"persistentVolumeClaimName": claim,
+			},
+		},
+	}}
+
+	_, err := m.k8sClient.Resource(volumeSnapshotResource).Namespace(rel.Namespace).Create(ctx, snapshot, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+
+	if err := m.waitSnapshotReady(ctx, rel.Namespace, name); err != nil {
+		return fmt.Errorf("VolumeSnapshot %s: %w", name, err)
+	}
+
+	m.logger.Info("Snapshotted claim", zap.String("Claim", claim), zap.String("VolumeSnapshot", name))
+
+	return nil
+}
+
+// waitSnapshotReady waits until a VolumeSnapshot is ready to use, failing
+// when the snapshotter reports an error or snapshotReadyTimeout passes.
+func (m *Manager) waitSnapshotReady(ctx context.Context, namespace, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, snapshotReadyTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(snapshotPollInterval)
+	defer ticker.Stop()
+
+	client := m.k8sClient.Resource(volumeSnapshotResource).Namespace(namespace)
+
+	for {
+		obj, err := client.Get(ctx, name, metav1.GetOptions{})
+		if err != nil && ctx.Err() == nil {
+			return err
+		}
+
+		if err == nil {
+			if ready, _, _ := unstructured.NestedBool(obj.Object, "status", "readyToUse"); ready {
+				return nil
+			}
+
+			if message, found, _ := unstructured.NestedString(obj.Object, "status", "error", "message"); found {
+				return fmt.Errorf("failed: %s", message)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("not ready to use after %s", snapshotReadyTimeout)
+		case <-ticker.C:
+		}
+	}
+}
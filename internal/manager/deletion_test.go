@@ -0,0 +1,121 @@
+package manager
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+const claimManifest = `---
+# Source: minecraft-java/templates/pvc.yaml
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: survival-data
+spec:
+  resources:
+    requests:
+      storage: 10Gi
+---
+# Source: minecraft-java/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: survival
+`
+
+func TestDeletionPolicy(t *testing.T) {
+	tests := map[string]string{
+		"":                     DeletionPolicyRetainData,
+		DeletionPolicyDelete:   DeletionPolicyDelete,
+		DeletionPolicySnapshot: DeletionPolicySnapshot,
+		DeletionPolicyOrphan:   DeletionPolicyOrphan,
+	}
+
+	for policy, want := range tests {
+		gameServer := &crds.GameServer{Spec: crds.GameServerSpec{DeletionPolicy: policy}}
+
+		if got := deletionPolicy(gameServer); got != want {
+			t.Errorf("deletionPolicy(%q) = %q, want %q", policy, got, want)
+		}
+	}
+}
+
+func TestKeepClaims(t *testing.T) {
+	kept, err := keepClaims(claimManifest)
+	if err != nil {
+		t.Fatalf("keepClaims: %v", err)
+	}
+
+	policies := make(map[string]string)
+
+	for _, document := range strings.Split(kept, "---\n") {
+		if strings.TrimSpace(document) == "" {
+			continue
+		}
+
+		node, err := yaml.Parse(document)
+		if err != nil {
+			t.Fatalf("parse kept manifest: %v", err)
+		}
+
+		policies[node.GetKind()] = node.GetAnnotations()[kube.ResourcePolicyAnno]
+	}
+
+	want := map[string]string{"PersistentVolumeClaim": kube.KeepPolicy, "Service": ""}
+	if !reflect.DeepEqual(policies, want) {
+		t.Errorf("resource policies = %v, want %v", policies, want)
+	}
+
+	if !strings.Contains(kept, "storage: 10Gi") {
+		t.Errorf("kept manifest lost the claim's spec:\n%s", kept)
+	}
+}
+
+func TestReleaseClaims(t *testing.T) {
+	claim := func(instance string) map[string]any {
+		return map[string]any{"metadata": map[string]any{"labels": map[string]any{helmInstanceLabel: instance}}}
+	}
+
+	m, cluster := newFakeManager(t,
+		testObject("v1", "PersistentVolumeClaim", "games", "survival-data", claim("survival")),
+		testObject("v1", "PersistentVolumeClaim", "games", "world-survival-0", claim("survival")),
+		testObject("v1", "PersistentVolumeClaim", "games", "world-creative-0", claim("creative")),
+	)
+
+	rel := &release.Release{Name: "survival", Namespace: "games", Manifest: claimManifest}
+
+	claims, err := m.releaseClaims(context.Background(), rel)
+	if err != nil {
+		t.Fatalf("releaseClaims: %v", err)
+	}
+
+	if want := []string{"survival-data", "world-survival-0"}; !reflect.DeepEqual(claims, want) {
+		t.Errorf("releaseClaims = %q, want %q", claims, want)
+	}
+
+	owner := types.NamespacedName{Namespace: "games", Name: "survival-gs"}
+
+	if err := m.labelRetainedClaim(context.Background(), rel, owner, "world-survival-0"); err != nil {
+		t.Fatalf("labelRetainedClaim: %v", err)
+	}
+
+	retained := cluster.get("/api/v1/namespaces/games/persistentvolumeclaims/world-survival-0")
+
+	labels := retained.GetLabels()
+	if labels[retainedGameServerLabel] != "survival-gs" || labels[retainedReleaseLabel] != "survival" || labels[helmInstanceLabel] != "survival" {
+		t.Errorf("retained claim labels = %v", labels)
+	}
+
+	if retained.GetAnnotations()[retainedAtAnnotation] == "" {
+		t.Errorf("retained claim lacks %s", retainedAtAnnotation)
+	}
+}
@@ -0,0 +1,291 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+// dryRunAnnotation makes the operator render a GameServer's release into its
+// dry-run ConfigMap instead of deploying it.
+const dryRunAnnotation = "goopy.us/dry-run"
+
+const (
+	dryRunManifestKey = "manifest.yaml"
+	dryRunDiffKey     = "diff.patch"
+	dryRunHashKey     = "hash"
+)
+
+// ConditionDryRun is True while a GameServer is only previewed, and points at
+// the ConfigMap holding the preview.
+const ConditionDryRun = "DryRun"
+
+// dryRunConfigMapName is the ConfigMap previews of a GameServer are stored in.
+func dryRunConfigMapName(gameServer *crds.GameServer) string {
+	return gameServer.Name + "-dry-run"
+}
+
+// isDryRun reports whether a GameServer should be previewed instead of deployed.
+func (m *Manager) isDryRun(gameServer *crds.GameServer) bool {
+	return m.options.DryRun || gameServer.Annotations[dryRunAnnotation] == "true"
+}
+
+// preview renders the release a GameServer would deploy, client-side, and
+// stores the manifests and their diff against the live release in the
+// GameServer's dry-run ConfigMap. Secrets are redacted. Previews are only
+// rendered again when the values or chart change.
+func (m *Manager) preview(ctx context.Context, actionConfig *action.Configuration, gameServer *crds.GameServer, g *game, resolved *resolvedValues, releaseName string) error {
+	name := dryRunConfigMapName(gameServer)
+
+	current, err := m.k8sClient.Resource(configMapResource).Namespace(gameServer.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	if err == nil {
+		hash, _, _ := unstructured.NestedString(current.Object, "data", dryRunHashKey)
+		if hash == resolved.hash {
+			return nil
+		}
+	}
+
+	live, err := liveRelease(actionConfig, releaseName)
+	if err != nil {
+		return err
+	}
+
+	var rendered *release.Release
+
+	if live == nil {
+		installer := action.NewInstall(actionConfig)
+		installer.Namespace = gameServer.Namespace
+		installer.ReleaseName = releaseName
+		installer.DryRunOption = "client"
+		installer.HideSecret = true
+		installer.PostRenderer = newPostRenderer(gameServer)
+
+		rendered, err = installer.RunWithContext(ctx, g.chart, resolved.values)
+	} else {
+		upgrader := action.NewUpgrade(actionConfig)
+		upgrader.Namespace = gameServer.Namespace
+		upgrader.DryRunOption = "client"
+		upgrader.HideSecret = true
+		upgrader.PostRenderer = newPostRenderer(gameServer)
+
+		rendered, err = upgrader.RunWithContext(ctx, releaseName, g.chart, resolved.values)
+	}
+
+	if err != nil {
+		m.logger.Error("Failed to render dry-run", zap.String("ReleaseName", releaseName), zap.Error(err))
+		m.recordFailure(ctx, gameServer, "DryRunFailed", err)
+
+		return err
+	}
+
+	liveManifest := ""
+	if live != nil {
+		liveManifest = live.Manifest
+	}
+
+	diff, err := manifestDiff(liveManifest, rendered.Manifest)
+	if err != nil {
+		return err
+	}
+
+	err = m.applyConfigMap(ctx, gameServer, name, map[string]string{
+		dryRunManifestKey: rendered.Manifest,
+		dryRunDiffKey:     diff,
+		dryRunHashKey:     resolved.hash,
+	}, true)
+	if err != nil {
+		return err
+	}
+
+	m.logger.Info("Rendered dry-run", zap.String("ReleaseName", releaseName), zap.String("ConfigMap", name))
+
+	message := fmt.Sprintf("rendered into ConfigMap %s, not deployed", name)
+	if diff == "" {
+		message = fmt.Sprintf("rendered into ConfigMap %s, no changes to the live release", name)
+	}
+
+	return m.updateStatus(ctx, gameServer.Namespace, gameServer.Name, func(status *crds.GameServerStatus) {
+		setCondition(status, ConditionDryRun, ConditionTrue, "Rendered", message)
+	})
+}
+
+// liveRelease returns the deployed release named releaseName, or nil when
+// there is none.
+func liveRelease(actionConfig *action.Configuration, releaseName string) (*release.Release, error) {
+	releases, err := getInstalledCharts(actionConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rel := range releases {
+		if rel.Name == releaseName {
+			return action.NewGet(actionConfig).Run(releaseName)
+		}
+	}
+
+	return nil, nil
+}
+
+// manifestDiff compares two Helm manifests resource by resource, as a
+// unified diff with each resource's full contents as context.
+func manifestDiff(live, rendered string) (string, error) {
+	liveResources, err := splitManifest(live)
+	if err != nil {
+		return "", err
+	}
+
+	renderedResources, err := splitManifest(rendered)
+	if err != nil {
+		return "", err
+	}
+
+	keys := make(map[string]struct{})
+	for key := range liveResources {
+		keys[key] = struct{}{}
+	}
+
+	for key := range renderedResources {
+		keys[key] = struct{}{}
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+
+	sort.Strings(sorted)
+
+	var diff strings.Builder
+
+	for _, key := range sorted {
+		before, after := liveResources[key], renderedResources[key]
+		if before == after {
+			continue
+		}
+
+		fromFile, toFile := "a/"+key, "b/"+key
+		if before == "" {
+			fromFile = "/dev/null"
+		}
+
+		if after == "" {
+			toFile = "/dev/null"
+		}
+
+		fmt.Fprintf(&diff, "--- %s\n+++ %s\n", fromFile, toFile)
+
+		for _, line := range diffLines(splitLines(before), splitLines(after)) {
+			diff.WriteString(line)
+			diff.WriteString("\n")
+		}
+	}
+
+	return diff.String(), nil
+}
+
+// splitManifest splits a Helm manifest into its resources, keyed by
+// kind/namespace/name. Secrets are dropped.
+func splitManifest(manifest string) (map[string]string, error) {
+	resources := make(map[string]string)
+
+	for _, document := range strings.Split(manifest, "\n---") {
+		document = strings.TrimSpace(strings.TrimPrefix(document, "---"))
+		if document == "" {
+			continue
+		}
+
+		var meta struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+		}
+
+		if err := yaml.Unmarshal([]byte(document), &meta); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+
+		// rendered Secrets are hidden, so leave the live ones out too
+		if meta.Kind == "" || meta.Kind == "Secret" {
+			continue
+		}
+
+		key := strings.Join([]string{meta.Kind, meta.Metadata.Namespace, meta.Metadata.Name}, "/")
+		key = strings.ReplaceAll(key, "//", "/")
+		resources[key] = document
+	}
+
+	return resources, nil
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, "\n")
+}
+
+// diffLines returns the lines of a and b prefixed with "-", "+" or " " along
+// their longest common subsequence.
+func diffLines(a, b []string) []string {
+	// lcs[i][j] is the length of the common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]string, 0, len(a)+len(b))
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, " "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "-"+a[i])
+			i++
+		default:
+			lines = append(lines, "+"+b[j])
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		lines = append(lines, "-"+a[i])
+	}
+
+	for ; j < len(b); j++ {
+		lines = append(lines, "+"+b[j])
+	}
+
+	return lines
+}
@@ -0,0 +1,162 @@
+package manager
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+const (
+	serviceManifest = "kind: Service\nmetadata:\n  name: survival\nspec:\n  port: 25565"
+	secretManifest  = "kind: Secret\nmetadata:\n  name: survival\ndata:\n  password: aHVudGVyMg=="
+)
+
+func TestSplitManifest(t *testing.T) {
+	manifest := "---\n# Source: chart/templates/service.yaml\n" + serviceManifest +
+		"\n---\n" + secretManifest +
+		"\n---\nkind: Deployment\nmetadata:\n  name: survival\n  namespace: games\n---\n"
+
+	resources, err := splitManifest(manifest)
+	if err != nil {
+		t.Fatalf("splitManifest: %v", err)
+	}
+
+	keys := make([]string, 0, len(resources))
+	for key := range resources {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	if want := []string{"Deployment/games/survival", "Service/survival"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("resources = %q, want %q", keys, want)
+	}
+}
+
+func TestManifestDiff(t *testing.T) {
+	changed := "kind: Service\nmetadata:\n  name: survival\nspec:\n  port: 25566"
+
+	tests := []struct {
+		name     string
+		live     string
+		rendered string
+		want     string
+	}{
+		{
+			name:     "unchanged",
+			live:     serviceManifest,
+			rendered: serviceManifest,
+		},
+		{
+			name:     "changed",
+			live:     serviceManifest,
+			rendered: changed,
+			want: "--- a/Service/survival\n+++ b/Service/survival\n" +
+				" kind: Service\n metadata:\n   name: survival\n spec:\n-  port: 25565\n+  port: 25566\n",
+		},
+		{
+			name:     "added",
+			rendered: serviceManifest,
+			want: "--- /dev/null\n+++ b/Service/survival\n" +
+				"+kind: Service\n+metadata:\n+  name: survival\n+spec:\n+  port: 25565\n",
+		},
+		{
+			name: "removed",
+			live: serviceManifest,
+			want: "--- a/Service/survival\n+++ /dev/null\n" +
+				"-kind: Service\n-metadata:\n-  name: survival\n-spec:\n-  port: 25565\n",
+		},
+		{
+			name:     "secrets left out",
+			live:     secretManifest,
+			rendered: "kind: Secret\nmetadata:\n  name: survival\ndata:\n  password: cmVkYWN0ZWQ=",
+		},
+	}
+
+	for _, test := range tests {
+		got, err := manifestDiff(test.live, test.rendered)
+		if err != nil {
+			t.Errorf("%s: manifestDiff: %v", test.name, err)
+
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("%s: manifestDiff =\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestIsDryRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		global     bool
+		annotation string
+		want       bool
+	}{
+		{"deployed", false, "", false},
+		{"operator dry run", true, "", true},
+		{"annotated", false, "true", true},
+		{"annotated false", false, "false", false},
+	}
+
+	for _, test := range tests {
+		m := &Manager{options: Options{DryRun: test.global}}
+
+		gameServer := &crds.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "survival"}}
+		if test.annotation != "" {
+			gameServer.Annotations = map[string]string{dryRunAnnotation: test.annotation}
+		}
+
+		if got := m.isDryRun(gameServer); got != test.want {
+			t.Errorf("%s: isDryRun = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDeleteDryRun(t *testing.T) {
+	gameServer := &crds.GameServer{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "games",
+		Name:        "survival",
+		Annotations: map[string]string{dryRunAnnotation: "true"},
+		Finalizers:  []string{releaseFinalizer},
+	}}
+
+	configMap := func(name, owner string) *unstructured.Unstructured {
+		obj := testObject("v1", "ConfigMap", "games", name, nil)
+		obj.SetLabels(map[string]string{managedByLabel: managedByValue, "goopy.us/gameserver": owner})
+
+		return obj
+	}
+
+	object := gameServerObject(t, gameServer)
+
+	m, cluster := newFakeManager(t,
+		object,
+		configMap("survival-dry-run", "survival"),
+		configMap("survival-config", "survival"),
+	)
+
+	if err := m.Delete(context.Background(), object.Object, "games"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if cluster.get("/api/v1/namespaces/games/configmaps/survival-dry-run") != nil {
+		t.Error("dry-run ConfigMap left behind")
+	}
+
+	// a release deployed before the GameServer was previewed keeps running
+	if cluster.get("/api/v1/namespaces/games/configmaps/survival-config") == nil {
+		t.Error("config ConfigMap of the deployed release deleted")
+	}
+
+	if finalizers := cluster.gameServer(t, "games", "survival").Finalizers; len(finalizers) != 0 {
+		t.Errorf("finalizers = %v, want the finalizer removed", finalizers)
+	}
+}
@@ -0,0 +1,66 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+const (
+	EventTypeNormal  = "Normal"
+	EventTypeWarning = "Warning"
+)
+
+// maxEventMessage is the longest message an Event is created with, longer
+// ones keep their tail.
+const maxEventMessage = 1024
+
+var eventResource = schema.GroupVersionResource{Version: "v1", Resource: "events"}
+
+// recordEvent creates an Event on a GameServer. Failures are logged, Events are
+// best effort.
+func (m *Manager) recordEvent(ctx context.Context, gameServer *crds.GameServer, eventType, reason, message string) {
+	message = tailString(message, maxEventMessage)
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	event := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Event",
+		"metadata": map[string]any{
+			"name":      fmt.Sprintf("%s.%x", gameServer.Name, time.Now().UnixNano()),
+			"namespace": gameServer.Namespace,
+		},
+		"involvedObject": map[string]any{
+			"apiVersion": crds.GameServerResource.GroupVersion().String(),
+			"kind":       "GameServer",
+			"name":       gameServer.Name,
+			"namespace":  gameServer.Namespace,
+			"uid":        string(gameServer.UID),
+		},
+		"type":           eventType,
+		"reason":         reason,
+		"message":        message,
+		"firstTimestamp": now,
+		"lastTimestamp":  now,
+		"count":          int64(1),
+		"source": map[string]any{
+			"component": "gameserver-operator",
+		},
+	}}
+
+	_, err := m.k8sClient.Resource(eventResource).Namespace(gameServer.Namespace).Create(ctx, event, metav1.CreateOptions{})
+	if err != nil {
+		m.logger.Error("Failed to record event",
+			zap.String("Name", gameServer.Name),
+			zap.String("Reason", reason),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,322 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+// fakeCluster is an in-memory API server holding the objects a test puts in
+// it. It gets, lists by label, creates, updates and deletes them, which is
+// all the manager asks of the API server outside of Helm.
+type fakeCluster struct {
+	mu       sync.Mutex
+	objects  map[string]map[string]any
+	version  int
+	requests []string
+}
+
+// newFakeManager returns a Manager talking to a fakeCluster holding objects.
+func newFakeManager(t *testing.T, objects ...*unstructured.Unstructured) (*Manager, *fakeCluster) {
+	t.Helper()
+
+	cluster := &fakeCluster{objects: make(map[string]map[string]any)}
+	for _, obj := range objects {
+		cluster.store(objectPath(obj), obj.Object)
+	}
+
+	server := httptest.NewServer(cluster)
+	t.Cleanup(server.Close)
+
+	client, err := dynamic.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("dynamic client: %v", err)
+	}
+
+	instanceMap, _ := crds.NewInstanceMap()
+
+	return &Manager{k8sClient: client, logger: zap.NewNop(), instanceMap: instanceMap}, cluster
+}
+
+// objectPath is the API path of an object, its resource being its lowercase
+// kind in the plural.
+func objectPath(obj *unstructured.Unstructured) string {
+	path := "/apis/" + obj.GetAPIVersion()
+	if !strings.Contains(obj.GetAPIVersion(), "/") {
+		path = "/api/" + obj.GetAPIVersion()
+	}
+
+	if obj.GetNamespace() != "" {
+		path += "/namespaces/" + obj.GetNamespace()
+	}
+
+	return path + "/" + strings.ToLower(obj.GetKind()) + "s/" + obj.GetName()
+}
+
+// get returns the object at path, nil when there is none.
+func (c *fakeCluster) get(path string) *unstructured.Unstructured {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	obj, ok := c.objects[path]
+	if !ok {
+		return nil
+	}
+
+	return &unstructured.Unstructured{Object: obj}
+}
+
+// writes lists the requests that changed something, as "METHOD path".
+func (c *fakeCluster) writes() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]string(nil), c.requests...)
+}
+
+func (c *fakeCluster) store(path string, obj map[string]any) {
+	c.version++
+
+	stored := &unstructured.Unstructured{Object: obj}
+	stored.SetResourceVersion(strconv.Itoa(c.version))
+	c.objects[path] = obj
+}
+
+func (c *fakeCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path, status := strings.CutSuffix(r.URL.Path, "/status")
+
+	if r.Method != http.MethodGet {
+		c.requests = append(c.requests, r.Method+" "+r.URL.Path)
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if obj, ok := c.objects[path]; ok {
+			writeJSON(w, http.StatusOK, obj)
+
+			return
+		}
+
+		if isCollection(path) {
+			selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+			if err != nil {
+				writeStatus(w, http.StatusBadRequest, metav1.StatusReasonBadRequest)
+
+				return
+			}
+
+			writeJSON(w, http.StatusOK, c.list(path, selector))
+
+			return
+		}
+
+		writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound)
+	case http.MethodPost:
+		obj, err := readObject(r.Body)
+		if err != nil {
+			writeStatus(w, http.StatusBadRequest, metav1.StatusReasonBadRequest)
+
+			return
+		}
+
+		path = path + "/" + obj.GetName()
+		if _, ok := c.objects[path]; ok {
+			writeStatus(w, http.StatusConflict, metav1.StatusReasonAlreadyExists)
+
+			return
+		}
+
+		c.store(path, obj.Object)
+		writeJSON(w, http.StatusCreated, obj.Object)
+	case http.MethodPut:
+		existing, ok := c.objects[path]
+		if !ok {
+			writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound)
+
+			return
+		}
+
+		obj, err := readObject(r.Body)
+		if err != nil {
+			writeStatus(w, http.StatusBadRequest, metav1.StatusReasonBadRequest)
+
+			return
+		}
+
+		// the status subresource only writes status, the object keeps it
+		if status {
+			existing["status"] = obj.Object["status"]
+			obj.Object = existing
+		} else if existingStatus, ok := existing["status"]; ok {
+			obj.Object["status"] = existingStatus
+		}
+
+		c.store(path, obj.Object)
+		writeJSON(w, http.StatusOK, obj.Object)
+	case http.MethodDelete:
+		if _, ok := c.objects[path]; !ok {
+			writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound)
+
+			return
+		}
+
+		delete(c.objects, path)
+		writeJSON(w, http.StatusOK, map[string]any{"apiVersion": "v1", "kind": "Status", "status": metav1.StatusSuccess})
+	default:
+		writeStatus(w, http.StatusMethodNotAllowed, metav1.StatusReasonMethodNotAllowed)
+	}
+}
+
+// isCollection tells collection paths from object paths by the number of
+// segments after the group version: resource, or namespaces/ns/resource.
+func isCollection(path string) bool {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	groupVersion := 3
+	if segments[0] == "api" {
+		groupVersion = 2
+	}
+
+	return (len(segments)-groupVersion)%2 == 1
+}
+
+func (c *fakeCluster) list(path string, selector labels.Selector) map[string]any {
+	paths := make([]string, 0)
+
+	for objectPath := range c.objects {
+		name, ok := strings.CutPrefix(objectPath, path+"/")
+		obj := &unstructured.Unstructured{Object: c.objects[objectPath]}
+
+		if ok && !strings.Contains(name, "/") && selector.Matches(labels.Set(obj.GetLabels())) {
+			paths = append(paths, objectPath)
+		}
+	}
+
+	sort.Strings(paths)
+
+	items := make([]any, 0, len(paths))
+	for _, objectPath := range paths {
+		items = append(items, c.objects[objectPath])
+	}
+
+	return map[string]any{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"metadata":   map[string]any{"resourceVersion": strconv.Itoa(c.version)},
+		"items":      items,
+	}
+}
+
+func readObject(body io.Reader) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	if err := json.NewDecoder(body).Decode(&obj.Object); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, obj any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(obj)
+}
+
+func writeStatus(w http.ResponseWriter, code int, reason metav1.StatusReason) {
+	writeJSON(w, code, map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Status",
+		"status":     metav1.StatusFailure,
+		"reason":     reason,
+		"code":       code,
+		"message":    fmt.Sprintf("%s %s", reason, http.StatusText(code)),
+	})
+}
+
+// testObject builds an object for a fakeCluster.
+func testObject(apiVersion, kind, namespace, name string, fields map[string]any) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: fields}
+	if obj.Object == nil {
+		obj.Object = make(map[string]any)
+	}
+
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+
+	return obj
+}
+
+// gameServerObject turns a GameServer into an object for a fakeCluster.
+func gameServerObject(t *testing.T, gameServer *crds.GameServer) *unstructured.Unstructured {
+	t.Helper()
+
+	fields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(gameServer)
+	if err != nil {
+		t.Fatalf("convert GameServer: %v", err)
+	}
+
+	return testObject("goopy.us/v1", "GameServer", gameServer.Namespace, gameServer.Name, fields)
+}
+
+// gameServer reads a GameServer back out of the cluster.
+func (c *fakeCluster) gameServer(t *testing.T, namespace, name string) *crds.GameServer {
+	t.Helper()
+
+	obj := c.get("/apis/goopy.us/v1/namespaces/" + namespace + "/gameservers/" + name)
+	if obj == nil {
+		t.Fatalf("GameServer %s/%s not found", namespace, name)
+	}
+
+	var gameServer crds.GameServer
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &gameServer); err != nil {
+		t.Fatalf("convert GameServer: %v", err)
+	}
+
+	return &gameServer
+}
+
+// findCondition returns the condition of the given type, nil when unset.
+func findCondition(status crds.GameServerStatus, condType string) *crds.GameServerCondition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == condType {
+			return &status.Conditions[i]
+		}
+	}
+
+	return nil
+}
+
+// toObject turns a typed object into one for a fakeCluster in the games
+// namespace.
+func toObject(t *testing.T, apiVersion, kind, name string, typed runtime.Object) *unstructured.Unstructured {
+	t.Helper()
+
+	fields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(typed)
+	if err != nil {
+		t.Fatalf("convert %s: %v", kind, err)
+	}
+
+	return testObject(apiVersion, kind, "games", name, fields)
+}
@@ -0,0 +1,73 @@
+package manager
+
+import (
+	"context"
+	"slices"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+// releaseFinalizer holds off the deletion of a GameServer until its
+// deletion policy has run, so GameServers deleted while the operator isn't
+// watching are handled like the others.
+const releaseFinalizer = "goopy.us/release"
+
+// hasFinalizer reports whether a GameServer waits on the operator to be
+// deleted.
+func hasFinalizer(gameServer *crds.GameServer) bool {
+	return slices.Contains(gameServer.Finalizers, releaseFinalizer)
+}
+
+// addFinalizer makes the deletion of a GameServer wait for its deletion
+// policy. GameServers already being deleted are left alone.
+func (m *Manager) addFinalizer(ctx context.Context, gameServer *crds.GameServer) error {
+	if hasFinalizer(gameServer) {
+		return nil
+	}
+
+	return m.updateFinalizers(ctx, gameServer.Namespace, gameServer.Name, func(finalizers []string) []string {
+		return append(finalizers, releaseFinalizer)
+	})
+}
+
+// removeFinalizer lets the deletion of a GameServer go ahead.
+func (m *Manager) removeFinalizer(ctx context.Context, gameServer *crds.GameServer) error {
+	return m.updateFinalizers(ctx, gameServer.Namespace, gameServer.Name, func(finalizers []string) []string {
+		return slices.DeleteFunc(finalizers, func(finalizer string) bool { return finalizer == releaseFinalizer })
+	})
+}
+
+// updateFinalizers applies mutate to the latest finalizers of a GameServer,
+// retrying on conflicts.
+func (m *Manager) updateFinalizers(ctx context.Context, namespace, name string, mutate func([]string) []string) error {
+	client := m.k8sClient.Resource(crds.GameServerResource).Namespace(namespace)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := client.Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		finalizers := obj.GetFinalizers()
+		mutated := mutate(slices.Clone(finalizers))
+
+		// finalizers can't be added to objects being deleted
+		if slices.Equal(finalizers, mutated) || (obj.GetDeletionTimestamp() != nil && len(mutated) > len(finalizers)) {
+			return nil
+		}
+
+		obj.SetFinalizers(mutated)
+
+		_, err = client.Update(ctx, obj, metav1.UpdateOptions{})
+
+		return err
+	})
+}
@@ -0,0 +1,210 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+	"github.com/Sackbuoy/gameserver-operator/internal/games"
+)
+
+// ConditionGameConfigApplied is True once spec.gameConfig is rendered and in
+// effect in the running game.
+const ConditionGameConfigApplied = "GameConfigApplied"
+
+// gameConfigMapName is the ConfigMap the rendered config files of a
+// GameServer are published in, for charts to mount.
+func gameConfigMapName(gameServer *crds.GameServer) string {
+	return gameServer.Name + "-config"
+}
+
+// syncGameConfig renders spec.gameConfig with the game's driver into the
+// config ConfigMap of a GameServer, and puts changed settings into effect:
+// through the console where the driver can change all of them live, or else
+// by stopping the game and restarting its workloads. Nothing runs before the
+// first release, and restarting is set when the game restarts anyway, so
+// rendering is all there is to do then.
+func (m *Manager) syncGameConfig(ctx context.Context, gameServer *crds.GameServer, releaseName string, restarting bool) {
+	previous := gameServer.Status.GameConfig
+
+	if (len(gameServer.Spec.GameConfig) == 0 && previous == nil) || m.isDryRun(gameServer) {
+		return
+	}
+
+	driver := games.Lookup(gameServer.Spec.GameType)
+
+	files, err := driver.RenderConfig(gameServer.Spec.GameConfig)
+	if err != nil {
+		m.setGameConfigCondition(ctx, gameServer, ConditionFalse, "RenderFailed", err.Error())
+
+		return
+	}
+
+	hash, err := hashValues(map[string]any{"files": files})
+	if err != nil || (previous != nil && previous.Hash == hash) {
+		return
+	}
+
+	name := gameConfigMapName(gameServer)
+
+	if err := m.applyConfigMap(ctx, gameServer, name, files, false); err != nil {
+		m.logger.Error("Failed to publish game config", zap.String("Name", gameServer.Name), zap.Error(err))
+		m.setGameConfigCondition(ctx, gameServer, ConditionFalse, "PublishFailed", err.Error())
+
+		return
+	}
+
+	settings := settingStrings(gameServer.Spec.GameConfig)
+
+	reason, message := "Rendered", fmt.Sprintf("Rendered into ConfigMap %s", name)
+
+	if gameServer.Status.HelmRelease != nil && !restarting {
+		var previousSettings map[string]string
+		if previous != nil {
+			previousSettings = previous.Settings
+		}
+
+		changed := changedSettings(previousSettings, settings)
+
+		reason, message, err = m.applySettings(ctx, gameServer, driver, releaseName, changed)
+		if err != nil {
+			m.logger.Error("Failed to apply game config", zap.String("Name", gameServer.Name), zap.Error(err))
+			m.setGameConfigCondition(ctx, gameServer, ConditionFalse, reason, message)
+
+			return
+		}
+	}
+
+	now := metav1.Now()
+
+	err = m.updateStatus(ctx, gameServer.Namespace, gameServer.Name, func(status *crds.GameServerStatus) {
+		status.GameConfig = &crds.GameConfigStatus{
+			ConfigMap:   name,
+			Hash:        hash,
+			Settings:    settings,
+			LastApplied: &now,
+		}
+
+		setCondition(status, ConditionGameConfigApplied, ConditionTrue, reason, message)
+	})
+	if err != nil {
+		m.logger.Error("Failed to update GameServer status", zap.String("Name", gameServer.Name), zap.Error(err))
+	}
+}
+
+// applySettings puts changed settings into effect in the running game, and
+// returns the reason and message to report. Settings are only changed live
+// when every one of them can be, restarting applies them all at once.
+func (m *Manager) applySettings(ctx context.Context, gameServer *crds.GameServer, driver games.Driver, releaseName string, changed []string) (string, string, error) {
+	if len(changed) == 0 {
+		return "Rendered", fmt.Sprintf("Rendered into ConfigMap %s", gameConfigMapName(gameServer)), nil
+	}
+
+	if live, ok := driver.(games.LiveSettings); ok && !restartRequired(gameServer, live, changed) {
+		err := m.applyLive(ctx, gameServer, live, changed)
+		if err == nil {
+			m.recordEvent(ctx, gameServer, EventTypeNormal, "GameConfigApplied",
+				fmt.Sprintf("Changed %s on the running game", strings.Join(changed, ", ")))
+
+			return "AppliedLive", fmt.Sprintf("Changed %s without restarting", strings.Join(changed, ", ")), nil
+		}
+
+		m.logger.Error("Failed to change settings live, restarting instead", zap.String("Name", gameServer.Name), zap.Error(err))
+	}
+
+	m.stopGame(ctx, gameServer, "restarting to apply new settings")
+
+	if err := m.restartWorkloads(ctx, gameServer, releaseName); err != nil {
+		return "RestartFailed", err.Error(), err
+	}
+
+	m.recordEvent(ctx, gameServer, EventTypeNormal, "GameConfigApplied",
+		fmt.Sprintf("Restarted the game to change %s", strings.Join(changed, ", ")))
+
+	return "Restarted", fmt.Sprintf("Restarted to change %s", strings.Join(changed, ", ")), nil
+}
+
+// restartRequired reports whether any of the changed settings only takes
+// effect after a restart. Removed settings fall back to the game's defaults
+// on restart only.
+func restartRequired(gameServer *crds.GameServer, live games.LiveSettings, changed []string) bool {
+	for _, key := range changed {
+		if _, set := gameServer.Spec.GameConfig[key]; !set || live.RestartRequired(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *Manager) applyLive(ctx context.Context, gameServer *crds.GameServer, live games.LiveSettings, changed []string) error {
+	console, err := m.console(ctx, gameServer)
+	if err != nil {
+		return err
+	}
+	defer console.Close()
+
+	for _, key := range changed {
+		if err := live.ApplySetting(ctx, console, key, gameServer.Spec.GameConfig[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Manager) setGameConfigCondition(ctx context.Context, gameServer *crds.GameServer, condStatus, reason, message string) {
+	err := m.updateStatus(ctx, gameServer.Namespace, gameServer.Name, func(status *crds.GameServerStatus) {
+		setCondition(status, ConditionGameConfigApplied, condStatus, reason, message)
+	})
+	if err != nil {
+		m.logger.Error("Failed to update GameServer status", zap.String("Name", gameServer.Name), zap.Error(err))
+	}
+}
+
+// settingStrings spells settings out for status, scalars the way config
+// files do and the rest as JSON.
+func settingStrings(settings map[string]any) map[string]string {
+	spelled := make(map[string]string, len(settings))
+
+	for key, value := range settings {
+		if formatted, err := games.FormatValue(value); err == nil {
+			spelled[key] = formatted
+
+			continue
+		}
+
+		encoded, _ := json.Marshal(value)
+		spelled[key] = string(encoded)
+	}
+
+	return spelled
+}
+
+// changedSettings lists the keys set, changed or removed between two sets of
+// settings, sorted.
+func changedSettings(before, after map[string]string) []string {
+	var changed []string
+
+	for key, value := range after {
+		if previous, ok := before[key]; !ok || previous != value {
+			changed = append(changed, key)
+		}
+	}
+
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed
+}
@@ -0,0 +1,191 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+// RunGarbageCollector collects orphaned releases every Options.GCInterval
+// until ctx is done.
+func (m *Manager) RunGarbageCollector(ctx context.Context) {
+	ticker := time.NewTicker(m.options.GCInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := m.CollectOrphans(ctx)
+			if err != nil {
+				m.logger.Error("Failed to collect orphaned releases", zap.Error(err))
+			}
+		}
+	}
+}
+
+// CollectOrphans applies the deletion policy recorded on releases deployed
+// for GameServers that no longer exist, such as ones whose finalizer was
+// removed by hand. With Options.GCDryRun or Options.DryRun they are only
+// reported.
+//
+// Releases are matched to their owner by UID, so the release of a GameServer
+// recreated under the same name is an orphan too; the new GameServer reports
+// the collision until the old release is gone, then installs its own.
+func (m *Manager) CollectOrphans(ctx context.Context) error {
+	for _, storageDriver := range m.gcStorageDrivers() {
+		err := m.collectOrphans(ctx, storageDriver)
+		if err != nil {
+			m.logger.Error("Failed to collect orphaned releases",
+				zap.String("StorageDriver", storageDriver),
+				zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// gcStorageDrivers are the storage drivers orphans are looked for in. SQL
+// needs a connection string, so it is only scanned when it is the default.
+func (m *Manager) gcStorageDrivers() []string {
+	storageDrivers := []string{StorageDriverSecret, StorageDriverConfigMap}
+	if m.options.StorageDriver == StorageDriverSQL {
+		storageDrivers = append(storageDrivers, StorageDriverSQL)
+	}
+
+	return storageDrivers
+}
+
+func (m *Manager) collectOrphans(ctx context.Context, storageDriver string) error {
+	actionConfig, err := m.newActionConfig("", storageDriver)
+	if err != nil {
+		return err
+	}
+
+	lister := action.NewList(actionConfig)
+	lister.All = true
+	lister.AllNamespaces = true
+	lister.Selector = fmt.Sprintf("%s=%s", managedByLabel, managedByValue)
+	lister.SetStateMask()
+
+	releases, err := lister.Run()
+	if err != nil {
+		return fmt.Errorf("failed to list managed releases: %w", err)
+	}
+
+	for _, rel := range releases {
+		if rel.Info != nil && rel.Info.Status == release.StatusUninstalled {
+			continue
+		}
+
+		orphaned, err := m.isOrphaned(ctx, rel)
+		if err != nil {
+			m.logger.Error("Failed to look up release owner", zap.String("ReleaseName", rel.Name), zap.Error(err))
+
+			continue
+		}
+
+		if !orphaned {
+			continue
+		}
+
+		if m.options.GCDryRun || m.options.DryRun {
+			m.logger.Warn("Found orphaned release",
+				zap.String("ReleaseName", rel.Name),
+				zap.String("Namespace", rel.Namespace),
+				zap.String("OwnerUID", rel.Labels[ownerUIDLabel]))
+
+			continue
+		}
+
+		err = m.uninstallOrphan(ctx, rel, storageDriver)
+		if err != nil {
+			m.logger.Error("Failed to uninstall orphaned release", zap.String("ReleaseName", rel.Name), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// isOrphaned reports whether the GameServer a release was deployed for is
+// gone, or has been replaced by one of the same name. Releases without owner
+// labels are never orphans.
+func (m *Manager) isOrphaned(ctx context.Context, rel *release.Release) (bool, error) {
+	namespace, name, uid := rel.Labels[ownerNamespaceLabel], rel.Labels[ownerNameLabel], rel.Labels[ownerUIDLabel]
+	if namespace == "" || name == "" || uid == "" {
+		return false, nil
+	}
+
+	owner, err := m.k8sClient.Resource(crds.GameServerResource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return string(owner.GetUID()) != uid, nil
+}
+
+func (m *Manager) uninstallOrphan(ctx context.Context, rel *release.Release, storageDriver string) error {
+	unlock, ok := m.lockRelease(rel.Namespace, rel.Name)
+	if !ok {
+		return nil
+	}
+	defer unlock()
+
+	// the owner may have been recreated since the releases were listed
+	orphaned, err := m.isOrphaned(ctx, rel)
+	if err != nil || !orphaned {
+		return err
+	}
+
+	actionConfig, err := m.newActionConfig(rel.Namespace, storageDriver)
+	if err != nil {
+		return err
+	}
+
+	owner := types.NamespacedName{Namespace: rel.Labels[ownerNamespaceLabel], Name: rel.Labels[ownerNameLabel]}
+
+	policy := orphanDeletionPolicy(rel)
+
+	err = m.uninstall(ctx, actionConfig, rel.Name, owner, nil, policy)
+	if err != nil {
+		return err
+	}
+
+	err = m.instanceMap.Delete(owner.Namespace, owner.Name)
+	if err != nil {
+		m.logger.Error("Failed to remove instance from internal cache", zap.Error(err))
+	}
+
+	m.logger.Info("Collected orphaned release",
+		zap.String("ReleaseName", rel.Name),
+		zap.String("Namespace", rel.Namespace),
+		zap.String("OwnerUID", rel.Labels[ownerUIDLabel]),
+		zap.String("DeletionPolicy", policy))
+
+	return nil
+}
+
+// orphanDeletionPolicy returns the deletion policy the owner of a release had.
+// Releases labeled before the policy was recorded keep their data.
+func orphanDeletionPolicy(rel *release.Release) string {
+	switch policy := rel.Labels[deletionPolicyLabel]; policy {
+	case DeletionPolicyDelete, DeletionPolicySnapshot, DeletionPolicyOrphan:
+		return policy
+	default:
+		return DeletionPolicyRetainData
+	}
+}
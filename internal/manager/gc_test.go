@@ -0,0 +1,74 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/release"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+func TestOrphanDeletionPolicy(t *testing.T) {
+	tests := map[string]string{
+		DeletionPolicyDelete:     DeletionPolicyDelete,
+		DeletionPolicySnapshot:   DeletionPolicySnapshot,
+		DeletionPolicyOrphan:     DeletionPolicyOrphan,
+		DeletionPolicyRetainData: DeletionPolicyRetainData,
+		"":                       DeletionPolicyRetainData,
+		"Bogus":                  DeletionPolicyRetainData,
+	}
+
+	for label, want := range tests {
+		rel := &release.Release{Labels: map[string]string{deletionPolicyLabel: label}}
+
+		if got := orphanDeletionPolicy(rel); got != want {
+			t.Errorf("orphanDeletionPolicy(%q) = %q, want %q", label, got, want)
+		}
+	}
+}
+
+func TestDeletionPolicyChangeIsNoCollision(t *testing.T) {
+	gameServer := &crds.GameServer{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "games", Name: "survival", UID: "1234"},
+		Spec:       crds.GameServerSpec{DeletionPolicy: DeletionPolicySnapshot},
+	}
+
+	rel := &release.Release{Name: "survival", Labels: releaseLabels(gameServer)}
+	rel.Labels[deletionPolicyLabel] = DeletionPolicyDelete
+
+	if err := ownerCollision(rel, gameServer); err != nil {
+		t.Errorf("ownerCollision = %v, a changed deletion policy is the same owner", err)
+	}
+}
+
+func TestIsOrphaned(t *testing.T) {
+	owner := &crds.GameServer{ObjectMeta: metav1.ObjectMeta{Namespace: "games", Name: "survival", UID: "1234"}}
+
+	m, _ := newFakeManager(t, gameServerObject(t, owner))
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   bool
+	}{
+		{"owner exists", releaseLabels(owner), false},
+		{"owner recreated", map[string]string{ownerNamespaceLabel: "games", ownerNameLabel: "survival", ownerUIDLabel: "5678"}, true},
+		{"owner gone", map[string]string{ownerNamespaceLabel: "games", ownerNameLabel: "creative", ownerUIDLabel: "1234"}, true},
+		{"no owner labels", map[string]string{managedByLabel: managedByValue}, false},
+	}
+
+	for _, test := range tests {
+		rel := &release.Release{Name: "survival", Labels: test.labels}
+
+		got, err := m.isOrphaned(context.Background(), rel)
+		if err != nil {
+			t.Errorf("%s: isOrphaned: %v", test.name, err)
+		}
+
+		if got != test.want {
+			t.Errorf("%s: isOrphaned = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
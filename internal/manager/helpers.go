@@ -1,14 +1,24 @@
 package manager
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 
-	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+	"go.uber.org/zap"
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/release"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
 )
 
+// defaultHelmTimeout matches the default of spec.helmChart.timeout.
+const defaultHelmTimeout = 300 * time.Second
+
 // Convert map[string]any to GameServer struct.
 func mapToGameServer(data map[string]any) (*crds.GameServer, error) {
 	// First, marshal the map to JSON
@@ -39,3 +49,84 @@ func getInstalledCharts(actionConfig *action.Configuration) ([]*release.Release,
 
 	return releases, nil
 }
+
+func (m *Manager) newActionConfig(namespace, storageDriver string) (*action.Configuration, error) {
+	actionConfig := new(action.Configuration)
+	if err := actionConfig.Init(m.helmSettings.RESTClientGetter(), namespace, storageDriver, m.logOutput); err != nil {
+		m.logger.Error("Failed to initialize Helm Action Config", zap.Error(err))
+
+		return nil, err
+	}
+
+	return actionConfig, nil
+}
+
+// helmTimeout is how long a release operation waits for its workloads.
+func helmTimeout(gameServer *crds.GameServer) time.Duration {
+	if gameServer.Spec.HelmChart.Timeout <= 0 {
+		return defaultHelmTimeout
+	}
+
+	return time.Duration(gameServer.Spec.HelmChart.Timeout) * time.Second
+}
+
+func loadChart(chartName string) (*chart.Chart, error) {
+	return loader.Load(fmt.Sprintf("/charts/%s", chartName))
+}
+
+// releaseLockRetryInterval is how often waitForRelease tries the lock.
+const releaseLockRetryInterval = time.Second
+
+// lockRelease serializes Helm operations on a release. It never blocks: ok is
+// false when another operation holds the release, and the caller should retry
+// on a later reconcile.
+func (m *Manager) lockRelease(namespace, releaseName string) (func(), bool) {
+	value, _ := m.releaseLocks.LoadOrStore(namespace+"/"+releaseName, &sync.Mutex{})
+
+	mu, _ := value.(*sync.Mutex)
+	if !mu.TryLock() {
+		return nil, false
+	}
+
+	return mu.Unlock, true
+}
+
+// waitForRelease takes the lock of a release like lockRelease, waiting for
+// the operation holding it to finish, for deletions that only happen once.
+func (m *Manager) waitForRelease(ctx context.Context, namespace, releaseName string) (func(), error) {
+	ticker := time.NewTicker(releaseLockRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		if unlock, ok := m.lockRelease(namespace, releaseName); ok {
+			return unlock, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("another operation on %s is in progress: %w", releaseName, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// isDeployed reports whether the current generation of a GameServer has been
+// deployed with values matching valuesHash.
+func isDeployed(gameServer *crds.GameServer, valuesHash string) bool {
+	status := gameServer.Status
+
+	return status.ObservedGeneration == gameServer.Generation &&
+		status.HelmRelease != nil &&
+		status.HelmRelease.ValuesHash == valuesHash
+}
+
+// failedBefore reports whether the current generation of a GameServer already
+// failed to deploy with values matching valuesHash, so trying again would
+// only fail, and stop the game, again.
+func failedBefore(gameServer *crds.GameServer, valuesHash string) bool {
+	failed := gameServer.Status.FailedRelease
+
+	return failed != nil &&
+		failed.Generation == gameServer.Generation &&
+		failed.ValuesHash == valuesHash
+}
@@ -0,0 +1,58 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+func TestFailedBefore(t *testing.T) {
+	failed := &crds.FailedReleaseStatus{Generation: 4, ValuesHash: "abc", Reason: "UpgradeFailed"}
+
+	tests := []struct {
+		name       string
+		generation int64
+		hash       string
+		failed     *crds.FailedReleaseStatus
+		want       bool
+	}{
+		{"same generation and values", 4, "abc", failed, true},
+		{"spec changed", 5, "abc", failed, false},
+		{"values changed", 4, "def", failed, false},
+		{"nothing failed", 4, "abc", nil, false},
+	}
+
+	for _, test := range tests {
+		gameServer := &crds.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Generation: test.generation},
+			Status:     crds.GameServerStatus{FailedRelease: test.failed},
+		}
+
+		if got := failedBefore(gameServer, test.hash); got != test.want {
+			t.Errorf("%s: failedBefore = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestHelmTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout int
+		want    time.Duration
+	}{
+		{"unset", 0, defaultHelmTimeout},
+		{"negative", -5, defaultHelmTimeout},
+		{"set", 90, 90 * time.Second},
+	}
+
+	for _, test := range tests {
+		gameServer := &crds.GameServer{Spec: crds.GameServerSpec{HelmChart: crds.HelmChart{Timeout: test.timeout}}}
+
+		if got := helmTimeout(gameServer); got != test.want {
+			t.Errorf("%s: helmTimeout = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
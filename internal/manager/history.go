@@ -0,0 +1,185 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+// Helm storage drivers, see Options.StorageDriver.
+const (
+	StorageDriverSecret    = "secret"
+	StorageDriverConfigMap = "configmap"
+	StorageDriverSQL       = "sql"
+)
+
+// defaultMaxHistory and defaultMinKnownGood are used when neither the
+// operator nor a GameServer set history limits.
+const (
+	defaultMaxHistory   = 10
+	defaultMinKnownGood = 2
+)
+
+func validStorageDriver(storageDriver string) error {
+	switch storageDriver {
+	// Helm takes the plurals as well, as HELM_DRIVER may hold them
+	case "", StorageDriverSecret, "secrets", StorageDriverConfigMap, "configmaps", StorageDriverSQL:
+		return nil
+	default:
+		return fmt.Errorf("unknown Helm storage driver %q", storageDriver)
+	}
+}
+
+// storageDriver returns the Helm storage driver of a GameServer's release.
+// Like the release name, it sticks once the release is deployed.
+func (m *Manager) storageDriver(gameServer *crds.GameServer) string {
+	if deployed := gameServer.Status.HelmRelease; deployed != nil && deployed.StorageDriver != "" {
+		return deployed.StorageDriver
+	}
+
+	if gameServer.Spec.HelmChart.StorageDriver != "" {
+		return gameServer.Spec.HelmChart.StorageDriver
+	}
+
+	if m.options.StorageDriver != "" {
+		return m.options.StorageDriver
+	}
+
+	return StorageDriverSecret
+}
+
+// resolveStorageDriver returns the storage driver of a GameServer's release,
+// marking the GameServer as failed when its spec asks to move a deployed
+// release to another driver.
+func (m *Manager) resolveStorageDriver(ctx context.Context, gameServer *crds.GameServer) (string, error) {
+	storageDriver := m.storageDriver(gameServer)
+
+	if explicit := gameServer.Spec.HelmChart.StorageDriver; explicit != "" && explicit != storageDriver {
+		err := fmt.Errorf("spec.helmChart.storageDriver %q can't replace %q of the deployed release", explicit, storageDriver)
+		m.logger.Error("Failed to pick storage driver", zap.String("Name", gameServer.Name), zap.Error(err))
+		m.recordFailure(ctx, gameServer, "StorageDriverImmutable", err)
+
+		return "", err
+	}
+
+	return storageDriver, nil
+}
+
+// checkStorageDriver refuses to keep the release of a GameServer in plain
+// ConfigMaps when its values hold data read from Secrets, such as the RCON
+// password, marking the GameServer as failed.
+func (m *Manager) checkStorageDriver(ctx context.Context, gameServer *crds.GameServer, storageDriver string, resolved *resolvedValues) error {
+	err := sensitiveValuesStorage(storageDriver, resolved)
+	if err != nil {
+		m.logger.Error("Refusing storage driver", zap.String("Name", gameServer.Name), zap.Error(err))
+		m.recordFailure(ctx, gameServer, "SensitiveValuesInConfigMap", err)
+	}
+
+	return err
+}
+
+// sensitiveValuesStorage returns an error when storageDriver would store
+// sensitive values, which Helm keeps with every revision, readable by anyone
+// allowed to read ConfigMaps.
+func sensitiveValuesStorage(storageDriver string, resolved *resolvedValues) error {
+	if storageDriver != StorageDriverConfigMap && storageDriver != "configmaps" {
+		return nil
+	}
+
+	for _, layer := range resolved.layers {
+		if layer.sensitive {
+			return fmt.Errorf("storage driver %s would keep the values from %s in plain ConfigMaps, use %s or %s",
+				storageDriver, layer.source, StorageDriverSecret, StorageDriverSQL)
+		}
+	}
+
+	return nil
+}
+
+// maxHistory is how many revisions of a GameServer's release are kept.
+func (m *Manager) maxHistory(gameServer *crds.GameServer) int {
+	if gameServer.Spec.HelmChart.MaxHistory > 0 {
+		return gameServer.Spec.HelmChart.MaxHistory
+	}
+
+	if m.options.MaxHistory > 0 {
+		return m.options.MaxHistory
+	}
+
+	return defaultMaxHistory
+}
+
+// Installed reports whether the release of a GameServer exists.
+func (m *Manager) Installed(gameServer *crds.GameServer) (bool, error) {
+	releaseName, err := m.ReleaseName(gameServer)
+	if err != nil {
+		return false, err
+	}
+
+	actionConfig, err := m.newActionConfig(gameServer.Namespace, m.storageDriver(gameServer))
+	if err != nil {
+		return false, err
+	}
+
+	_, err = actionConfig.Releases.Last(releaseName)
+	if errors.Is(err, driver.ErrReleaseNotFound) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// pruneHistory deletes the oldest revisions of a release beyond maxHistory.
+// The latest revision and the newest minKnownGood revisions that were
+// successfully deployed are always kept, so there is something to roll back to.
+func (m *Manager) pruneHistory(actionConfig *action.Configuration, releaseName string, maxHistory int) {
+	minKnownGood := m.options.MinKnownGood
+	if minKnownGood <= 0 {
+		minKnownGood = defaultMinKnownGood
+	}
+
+	history, err := actionConfig.Releases.History(releaseName)
+	if err != nil {
+		m.logger.Error("Failed to read release history", zap.String("ReleaseName", releaseName), zap.Error(err))
+
+		return
+	}
+
+	// newest first
+	sort.Slice(history, func(i, j int) bool { return history[i].Version > history[j].Version })
+
+	knownGood := 0
+
+	for i, rel := range history {
+		good := rel.Info != nil &&
+			(rel.Info.Status == release.StatusDeployed || rel.Info.Status == release.StatusSuperseded)
+
+		keep := i == 0 || i < maxHistory || (good && knownGood < minKnownGood)
+		if good && keep {
+			knownGood++
+		}
+
+		if keep {
+			continue
+		}
+
+		if _, err := actionConfig.Releases.Delete(releaseName, rel.Version); err != nil {
+			m.logger.Error("Failed to prune release revision",
+				zap.String("ReleaseName", releaseName),
+				zap.Int("Revision", rel.Version),
+				zap.Error(err))
+
+			continue
+		}
+
+		m.logger.Info("Pruned release revision", zap.String("ReleaseName", releaseName), zap.Int("Revision", rel.Version))
+	}
+}
@@ -0,0 +1,143 @@
+package manager
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+func TestSensitiveValuesStorage(t *testing.T) {
+	plain := &resolvedValues{layers: []valuesLayer{{source: "spec.helmChart.valuesOverride"}}}
+	sensitive := &resolvedValues{layers: []valuesLayer{
+		{source: "spec.helmChart.valuesOverride"},
+		{source: "RCON Secret games/survival-rcon", sensitive: true},
+	}}
+
+	tests := []struct {
+		storageDriver string
+		resolved      *resolvedValues
+		refused       bool
+	}{
+		{StorageDriverConfigMap, sensitive, true},
+		{"configmaps", sensitive, true},
+		{StorageDriverConfigMap, plain, false},
+		{StorageDriverSecret, sensitive, false},
+		{StorageDriverSQL, sensitive, false},
+	}
+
+	for _, test := range tests {
+		err := sensitiveValuesStorage(test.storageDriver, test.resolved)
+		if (err != nil) != test.refused {
+			t.Errorf("sensitiveValuesStorage(%s, sensitive %v) = %v, want refused %v",
+				test.storageDriver, test.resolved == sensitive, err, test.refused)
+		}
+	}
+}
+
+func TestPruneHistory(t *testing.T) {
+	const (
+		deployed   = release.StatusDeployed
+		superseded = release.StatusSuperseded
+		failed     = release.StatusFailed
+	)
+
+	tests := []struct {
+		name         string
+		statuses     []release.Status
+		maxHistory   int
+		minKnownGood int
+		want         []int
+	}{
+		{
+			name:       "oldest pruned",
+			statuses:   []release.Status{superseded, superseded, superseded, superseded, superseded, deployed},
+			maxHistory: 3,
+			want:       []int{4, 5, 6},
+		},
+		{
+			name:       "known good kept past failures",
+			statuses:   []release.Status{superseded, superseded, failed, failed, failed},
+			maxHistory: 2,
+			want:       []int{1, 2, 4, 5},
+		},
+		{
+			name:       "latest always kept",
+			statuses:   []release.Status{deployed, failed, failed},
+			maxHistory: 0,
+			want:       []int{1, 3},
+		},
+		{
+			name:         "fewer known good",
+			statuses:     []release.Status{superseded, superseded, deployed},
+			maxHistory:   1,
+			minKnownGood: 1,
+			want:         []int{3},
+		},
+		{
+			name:       "within limit",
+			statuses:   []release.Status{superseded, failed},
+			maxHistory: 10,
+			want:       []int{1, 2},
+		},
+	}
+
+	for _, test := range tests {
+		actionConfig := &action.Configuration{Releases: storage.Init(driver.NewMemory())}
+
+		for i, status := range test.statuses {
+			rel := &release.Release{Name: "survival", Namespace: "games", Version: i + 1, Info: &release.Info{Status: status}}
+			if err := actionConfig.Releases.Create(rel); err != nil {
+				t.Fatalf("%s: store revision %d: %v", test.name, i+1, err)
+			}
+		}
+
+		m := &Manager{logger: zap.NewNop(), options: Options{MinKnownGood: test.minKnownGood}}
+		m.pruneHistory(actionConfig, "survival", test.maxHistory)
+
+		history, err := actionConfig.Releases.History("survival")
+		if err != nil {
+			t.Fatalf("%s: History: %v", test.name, err)
+		}
+
+		kept := make([]int, 0, len(history))
+		for _, rel := range history {
+			kept = append(kept, rel.Version)
+		}
+
+		sort.Ints(kept)
+
+		if !reflect.DeepEqual(kept, test.want) {
+			t.Errorf("%s: kept revisions %v, want %v", test.name, kept, test.want)
+		}
+	}
+}
+
+func TestMaxHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    int
+		options int
+		want    int
+	}{
+		{"defaults", 0, 0, defaultMaxHistory},
+		{"operator", 0, 5, 5},
+		{"spec wins", 3, 5, 3},
+	}
+
+	for _, test := range tests {
+		m := &Manager{options: Options{MaxHistory: test.options}}
+		gameServer := &crds.GameServer{Spec: crds.GameServerSpec{HelmChart: crds.HelmChart{MaxHistory: test.spec}}}
+
+		if got := m.maxHistory(gameServer); got != test.want {
+			t.Errorf("%s: maxHistory = %d, want %d", test.name, got, test.want)
+		}
+	}
+}
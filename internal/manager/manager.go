@@ -1,31 +1,87 @@
 package manager
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	"helm.sh/helm/v3/pkg/action"
-	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/release"
-
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
-	"sigs.k8s.io/kustomize/kyaml/yaml"
 
 	"github.com/Sackbuoy/gameserver-operator/internal/crds"
 )
 
+// Options are the operator-wide settings of a Manager.
+type Options struct {
+	// DefaultValuesFile is a YAML file of chart values applied beneath the
+	// values of every GameServer
+	DefaultValuesFile string
+
+	// DryRun previews every GameServer, as if it was annotated with
+	// goopy.us/dry-run
+	DryRun bool
+
+	// GCInterval is how often releases whose GameServer is gone are
+	// collected, zero disables collection
+	GCInterval time.Duration
+
+	// GCDryRun reports orphaned releases instead of uninstalling them
+	GCDryRun bool
+
+	// ReleaseNaming is the strategy releases are named by, ReleaseNamingName
+	// when empty
+	ReleaseNaming string
+
+	// StorageDriver is the Helm storage driver releases are kept in when a
+	// GameServer doesn't pick one
+	StorageDriver string
+
+	// MaxHistory is how many revisions of a release are kept when a
+	// GameServer doesn't say
+	MaxHistory int
+
+	// MinKnownGood is how many successfully deployed revisions pruning
+	// always keeps
+	MinKnownGood int
+
+	// ChartCacheTTL is how long a chart downloaded from a repository is
+	// used before it is downloaded again, zero disables caching
+	ChartCacheTTL time.Duration
+}
+
 type Manager struct {
+	options         Options
 	helmSettings    *cli.EnvSettings
 	installedCharts []*release.Release
 	instanceMap     *crds.CRDInstanceMap
 	k8sClient       *dynamic.DynamicClient
 	logger          *zap.Logger
 	logOutput       func(string, ...any)
+	releaseLocks    sync.Map
+	chartCache      sync.Map
+	manifestCache   sync.Map
+	lastObserved    sync.Map
+	deleting        sync.Map
+	commands        sync.Map
 }
 
-func New(k8sClient *dynamic.DynamicClient, logger *zap.Logger, instanceMap *crds.CRDInstanceMap) (*Manager, error) {
+func New(k8sClient *dynamic.DynamicClient, logger *zap.Logger, instanceMap *crds.CRDInstanceMap, options Options) (*Manager, error) {
+	if err := validReleaseNaming(options.ReleaseNaming); err != nil {
+		return nil, err
+	}
+
+	if err := validStorageDriver(options.StorageDriver); err != nil {
+		return nil, err
+	}
+
 	settings := cli.New()
 
 	logOutput := func(format string, args ...any) {
@@ -34,6 +90,7 @@ func New(k8sClient *dynamic.DynamicClient, logger *zap.Logger, instanceMap *crds
 	}
 	// Create a new Helm install action
 	return &Manager{
+		options:      options,
 		helmSettings: settings,
 		k8sClient:    k8sClient,
 		instanceMap:  instanceMap,
@@ -42,40 +99,107 @@ func New(k8sClient *dynamic.DynamicClient, logger *zap.Logger, instanceMap *crds
 	}, nil
 }
 
-func (m *Manager) Create(crdObject map[string]any, chartName, releaseName, namespace string) error {
-	actionConfig := new(action.Configuration)
-	if err := actionConfig.Init(m.helmSettings.RESTClientGetter(), namespace, os.Getenv("HELM_DRIVER"), m.logOutput); err != nil {
-		m.logger.Error("Failed to initialize Helm Action Config", zap.Error(err))
+func (m *Manager) Create(ctx context.Context, crdObject map[string]any, namespace string) error {
+	gameServer, err := mapToGameServer(crdObject)
+	if err != nil {
+		m.logger.Error("Failed to install chart", zap.Error(err))
+
+		return err
+	}
+
+	// Delete runs the deletion policy of GameServers being deleted
+	if gameServer.DeletionTimestamp != nil {
+		return nil
+	}
+
+	releaseName, err := m.resolveReleaseName(ctx, gameServer)
+	if err != nil {
+		return err
+	}
+
+	storageDriver, err := m.resolveStorageDriver(ctx, gameServer)
+	if err != nil {
+		return err
+	}
+
+	actionConfig, err := m.newActionConfig(namespace, storageDriver)
+	if err != nil {
+		return err
 	}
 
 	installer := action.NewInstall(actionConfig)
 	installer.Namespace = namespace
 	installer.ReleaseName = releaseName
 
-	gameServer, err := mapToGameServer(crdObject)
-	if err != nil {
-		m.logger.Error("Failed to install chart", zap.Error(err))
-	}
+	unlock, ok := m.lockRelease(namespace, releaseName)
+	if !ok {
+		m.logger.Info("Release operation already in progress", zap.String("ReleaseName", releaseName))
 
-	chartPath := fmt.Sprintf("/charts/%s", chartName)
+		return nil
+	}
+	defer unlock()
 
-	chart, err := loader.Load(chartPath)
+	game, resolved, err := m.prepare(ctx, gameServer, specObject(crdObject))
 	if err != nil {
-		m.logger.Error("Failed to load chart", zap.Error(err))
+		return err
+	}
 
+	if err := m.checkStorageDriver(ctx, gameServer, storageDriver, resolved); err != nil {
 		return err
 	}
 
-	valuesOverride := make(map[string]any)
+	if m.isDryRun(gameServer) {
+		return m.preview(ctx, actionConfig, gameServer, game, resolved, releaseName)
+	}
+
+	if err := m.addFinalizer(ctx, gameServer); err != nil {
+		m.logger.Error("Failed to add finalizer", zap.String("Name", gameServer.Name), zap.Error(err))
 
-	err = yaml.Unmarshal([]byte(gameServer.Spec.HelmChart.ValuesOverride), &valuesOverride)
+		return err
+	}
+
+	live, err := liveRelease(actionConfig, releaseName)
 	if err != nil {
-		m.logger.Error("Failed to parse values Overrides")
+		return err
 	}
 
-	chartInstall, err := installer.Run(chart, valuesOverride)
+	if live != nil {
+		if err := ownerCollision(live, gameServer); err != nil {
+			return m.refuseCollision(ctx, gameServer, err)
+		}
+
+		if !isManaged(live, gameServer) {
+			return m.adopt(ctx, actionConfig, gameServer, game, resolved, live)
+		}
+
+		// already installed, upgrades are left to Update
+		return nil
+	}
+
+	if failedBefore(gameServer, resolved.hash) {
+		m.logger.Debug("Not retrying failed install until the spec or values change", zap.String("ReleaseName", releaseName))
+
+		return nil
+	}
+
+	m.publishPlayerFiles(ctx, gameServer)
+	m.syncGameConfig(ctx, gameServer, releaseName, true)
+
+	installer.Timeout = helmTimeout(gameServer)
+	installer.Wait = true
+	installer.Atomic = true
+	installer.PostRenderer = newPostRenderer(gameServer)
+	installer.Labels = releaseLabels(gameServer)
+
+	stopProgress := m.trackProgress(ctx, gameServer, "Installing", installer.Timeout)
+	chartInstall, err := installer.RunWithContext(ctx, game.chart, resolved.values)
+	stopProgress()
+
 	if err != nil {
 		m.logger.Error("Failed to install chart", zap.Error(err))
+		m.recordFailedRelease(ctx, gameServer, "InstallFailed", resolved.hash, err)
+
+		return err
 	}
 
 	err = m.instanceMap.Create(gameServer)
@@ -83,8 +207,16 @@ func (m *Manager) Create(crdObject map[string]any, chartName, releaseName, names
 		m.logger.Error("Failed to add instance to internal cache", zap.Error(err))
 	}
 
+	err = m.recordRelease(ctx, gameServer, chartInstall, resolved)
+	if err != nil {
+		m.logger.Error("Failed to update GameServer status", zap.Error(err))
+	}
+
 	m.logger.Info("Successfully installed release", zap.String("ReleaseName", chartInstall.Name))
 
+	m.observeRelease(ctx, gameServer, chartInstall.Name, true)
+	m.runChartTests(ctx, actionConfig, gameServer, chartInstall.Name)
+
 	return nil
 }
 
@@ -93,57 +225,447 @@ func (m *Manager) Read() error {
 	return nil
 }
 
-// TODO.
-func (m *Manager) Update() error {
+// Update upgrades the release of a GameServer when its spec or resolved
+// values changed since it was last deployed.
+func (m *Manager) Update(ctx context.Context, crdObject map[string]any, namespace string) error {
+	gameServer, err := mapToGameServer(crdObject)
+	if err != nil {
+		m.logger.Error("Failed to upgrade chart", zap.Error(err))
+
+		return err
+	}
+
+	releaseName, err := m.resolveReleaseName(ctx, gameServer)
+	if err != nil {
+		return err
+	}
+
+	unlock, ok := m.lockRelease(namespace, releaseName)
+	if !ok {
+		return nil
+	}
+	defer unlock()
+
+	// the object handed in may predate a release operation that just finished,
+	// so work from the latest copy
+	gameServer, spec, err := m.getGameServer(ctx, namespace, gameServer.Name)
+	if err != nil {
+		return err
+	}
+
+	if gameServer.DeletionTimestamp != nil {
+		return nil
+	}
+
+	game, resolved, err := m.prepare(ctx, gameServer, spec)
+	if err != nil {
+		return err
+	}
+
+	rotated := false
+
+	if !m.isDryRun(gameServer) {
+		// GameServers deployed before the operator added finalizers get one
+		// on their next update
+		if err := m.addFinalizer(ctx, gameServer); err != nil {
+			m.logger.Error("Failed to add finalizer", zap.String("Name", gameServer.Name), zap.Error(err))
+
+			return err
+		}
+
+		rotated, err = m.rotateRCONPassword(ctx, gameServer)
+		if err != nil {
+			m.logger.Error("Failed to rotate RCON password", zap.String("Name", gameServer.Name), zap.Error(err))
+
+			return err
+		}
+	}
+
+	if rotated {
+		// the new password changes the values the chart gets
+		resolved, err = m.resolveValues(ctx, gameServer, game)
+		if err != nil {
+			m.recordFailure(ctx, gameServer, "ValuesFailed", err)
+
+			return err
+		}
+	}
+
+	if isDeployed(gameServer, resolved.hash) {
+		m.syncGameConfig(ctx, gameServer, releaseName, rotated)
+
+		if rotated {
+			m.restartForRotation(ctx, gameServer, releaseName)
+		}
+
+		m.observeRelease(ctx, gameServer, releaseName, false)
+
+		return nil
+	}
+
+	storageDriver, err := m.resolveStorageDriver(ctx, gameServer)
+	if err != nil {
+		return err
+	}
+
+	if err := m.checkStorageDriver(ctx, gameServer, storageDriver, resolved); err != nil {
+		return err
+	}
+
+	actionConfig, err := m.newActionConfig(namespace, storageDriver)
+	if err != nil {
+		return err
+	}
+
+	if m.isDryRun(gameServer) {
+		return m.preview(ctx, actionConfig, gameServer, game, resolved, releaseName)
+	}
+
+	live, err := liveRelease(actionConfig, releaseName)
+	if err != nil {
+		return err
+	}
+
+	if live != nil {
+		if err := ownerCollision(live, gameServer); err != nil {
+			return m.refuseCollision(ctx, gameServer, err)
+		}
+	}
+
+	if live != nil && !isManaged(live, gameServer) {
+		return m.adopt(ctx, actionConfig, gameServer, game, resolved, live)
+	}
+
+	// an atomic upgrade that failed was rolled back, trying it again would
+	// stop the game for nothing
+	if failedBefore(gameServer, resolved.hash) {
+		m.logger.Debug("Not retrying failed upgrade until the spec or values change", zap.String("ReleaseName", releaseName))
+
+		return nil
+	}
+
+	// rotating the RCON password already stopped the game
+	restarts := rotated
+
+	// charts that get the RCON password through their values roll the game
+	// onto a rotated one with the upgrade
+	rolls := false
+
+	if live != nil {
+		// upgrades that leave the pods alone don't need the game stopped
+		changes, err := restartsWorkloads(ctx, actionConfig, gameServer, game, resolved, live)
+		if err != nil {
+			m.logger.Error("Failed to tell whether the upgrade restarts the game", zap.String("ReleaseName", releaseName), zap.Error(err))
+		}
+
+		rolls = changes
+
+		if !rotated {
+			restarts = changes
+
+			if changes || err != nil {
+				m.stopGame(ctx, gameServer, "restarting for an update")
+			}
+		}
+	}
+
+	// files are in place before the upgrade restarts the game, or else put
+	// into effect on their own
+	m.publishPlayerFiles(ctx, gameServer)
+	m.syncGameConfig(ctx, gameServer, releaseName, restarts)
+
+	upgrader := action.NewUpgrade(actionConfig)
+	upgrader.Namespace = namespace
+	upgrader.Timeout = helmTimeout(gameServer)
+	upgrader.Wait = true
+	upgrader.Atomic = true
+	upgrader.CleanupOnFail = true
+	upgrader.PostRenderer = newPostRenderer(gameServer)
+	upgrader.Labels = releaseLabels(gameServer)
+
+	stopProgress := m.trackProgress(ctx, gameServer, "Upgrading", upgrader.Timeout)
+	chartUpgrade, err := upgrader.RunWithContext(ctx, releaseName, game.chart, resolved.values)
+	stopProgress()
+
+	// failed upgrades and their rollbacks add revisions too
+	m.pruneHistory(actionConfig, releaseName, m.maxHistory(gameServer))
+
+	if err != nil {
+		m.logger.Error("Failed to upgrade chart", zap.Error(err))
+		m.recordFailedRelease(ctx, gameServer, "UpgradeFailed", resolved.hash, err)
+
+		return err
+	}
+
+	err = m.instanceMap.Update(gameServer)
+	if err != nil {
+		m.logger.Error("Failed to update instance in internal cache", zap.Error(err))
+	}
+
+	err = m.recordRelease(ctx, gameServer, chartUpgrade, resolved)
+	if err != nil {
+		m.logger.Error("Failed to update GameServer status", zap.Error(err))
+	}
+
+	m.logger.Info("Successfully upgraded release",
+		zap.String("ReleaseName", chartUpgrade.Name),
+		zap.Int("Revision", chartUpgrade.Version))
+
+	if rotated && !rolls {
+		m.restartForRotation(ctx, gameServer, chartUpgrade.Name)
+	}
+
+	m.observeRelease(ctx, gameServer, chartUpgrade.Name, true)
+	m.runChartTests(ctx, actionConfig, gameServer, chartUpgrade.Name)
+
 	return nil
 }
 
-func (m *Manager) Delete(releaseName, namespace string) error {
-	actionConfig := new(action.Configuration)
-	if err := actionConfig.Init(m.helmSettings.RESTClientGetter(), namespace, os.Getenv("HELM_DRIVER"), m.logOutput); err != nil {
-		m.logger.Error("Failed to initialize Helm Action Config", zap.Error(err))
+// resolveReleaseName returns the release name of a GameServer, marking it as
+// failed when the name is invalid.
+func (m *Manager) resolveReleaseName(ctx context.Context, gameServer *crds.GameServer) (string, error) {
+	releaseName, err := m.ReleaseName(gameServer)
+	if err != nil {
+		m.logger.Error("Failed to name release", zap.String("Name", gameServer.Name), zap.Error(err))
+		m.recordFailure(ctx, gameServer, "InvalidReleaseName", err)
+
+		return "", err
 	}
 
-	installedCharts, err := getInstalledCharts(actionConfig)
+	return releaseName, nil
+}
+
+// prepare resolves the template, game and values a GameServer is deployed
+// with. gameServer.Spec is replaced by the spec merged with its template.
+func (m *Manager) prepare(ctx context.Context, gameServer *crds.GameServer, spec map[string]any) (*game, *resolvedValues, error) {
+	err := m.applyTemplate(ctx, gameServer, spec)
 	if err != nil {
-		return err
+		m.logger.Error("Failed to apply template", zap.Error(err))
+		m.recordFailure(ctx, gameServer, "TemplateFailed", err)
+
+		return nil, nil, err
 	}
 
-	if len(installedCharts) == 0 {
-		return fmt.Errorf("No charts were found in %s", namespace)
+	game, err := m.loadGame(ctx, gameServer)
+	if err != nil {
+		m.logger.Error("Failed to load chart", zap.Error(err))
+		m.recordFailure(ctx, gameServer, "ChartLoadFailed", err)
+
+		return nil, nil, err
+	}
+
+	resolved, err := m.resolveValues(ctx, gameServer, game)
+	if err != nil {
+		m.logger.Error("Failed to resolve values", zap.Error(err))
+		m.recordFailure(ctx, gameServer, "ValuesFailed", err)
+
+		return nil, nil, err
 	}
 
-	// Fix: Check all charts before returning error
-	found := false
-	for _, chart := range installedCharts {
-		if releaseName == chart.Name {
-			found = true
+	err = validateValues(game, resolved.values)
+	if err != nil {
+		m.logger.Error("Values failed schema validation", zap.String("Name", gameServer.Name), zap.Error(err))
+
+		var invalid *valuesInvalidError
+		if errors.As(err, &invalid) {
+			m.recordInvalidValues(ctx, gameServer, invalid)
+		} else {
+			m.recordFailure(ctx, gameServer, "SchemaFailed", err)
+		}
+
+		return nil, nil, err
+	}
+
+	if len(resolved.conflicts) > 0 {
+		m.logger.Warn("Spec fields conflict with chart values", zap.Strings("Conflicts", resolved.conflicts))
+	}
+
+	return game, resolved, nil
+}
+
+// SyncValuesSource upgrades every GameServer in namespace that pulls values
+// from the given Secret or ConfigMap and whose values changed as a result.
+// The upgrades run in the background, so slow ones don't hold up the events
+// of other sources.
+func (m *Manager) SyncValuesSource(ctx context.Context, kind string, source *unstructured.Unstructured) error {
+	name, namespace := source.GetName(), source.GetNamespace()
+
+	// the operator's own objects and Helm's release storage change with
+	// every release, and are never values sources
+	labels := source.GetLabels()
+	if labels[managedByLabel] == managedByValue || labels["owner"] == "helm" {
+		return nil
+	}
+
+	// most Secrets and ConfigMaps aren't values sources, tell from the
+	// GameServers seen last before asking the API server
+	referenced := false
+
+	for _, gameServer := range m.instanceMap.List() {
+		if gameServer.Namespace == namespace && referencesValuesSource(gameServer, kind, name) {
+			referenced = true
 
 			break
 		}
 	}
 
-	if !found {
-		return fmt.Errorf("%s not installed in namespace %s", releaseName, namespace)
+	if !referenced {
+		return nil
+	}
+
+	list, err := m.k8sClient.Resource(crds.GameServerResource).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, instance := range list.Items {
+		gameServer, err := mapToGameServer(instance.Object)
+		if err != nil {
+			m.logger.Error("Failed to convert GameServer", zap.String("Name", instance.GetName()), zap.Error(err))
+
+			continue
+		}
+
+		if !referencesValuesSource(gameServer, kind, name) {
+			continue
+		}
+
+		go func() {
+			err := m.Update(ctx, instance.Object, namespace)
+			if err != nil {
+				m.logger.Error("Failed to sync values source",
+					zap.String("Source", fmt.Sprintf("%s %s/%s", kind, namespace, name)),
+					zap.String("Instance", instance.GetName()),
+					zap.Error(err))
+			}
+		}()
+	}
+
+	return nil
+}
+
+// Delete runs the deletion policy of a GameServer and then lets its deletion
+// go ahead. It is called for GameServers being deleted, both from their watch
+// events and from the reconciler, and retried until it succeeds.
+func (m *Manager) Delete(ctx context.Context, crdObject map[string]any, namespace string) error {
+	gameServer, err := mapToGameServer(crdObject)
+	if err != nil {
+		m.logger.Error("Failed to uninstall chart", zap.Error(err))
+
+		return err
+	}
+
+	// the watch and the reconciler both see the deletion
+	key := namespace + "/" + gameServer.Name
+	if _, busy := m.deleting.LoadOrStore(key, struct{}{}); busy {
+		return nil
+	}
+	defer m.deleting.Delete(key)
+
+	releaseName, err := m.ReleaseName(gameServer)
+	if err != nil {
+		// nothing can have been installed under an invalid name
+		return m.removeFinalizer(ctx, gameServer)
 	}
 
-	uninstaller := action.NewUninstall(actionConfig)
+	// previewed GameServers only ever created their preview
+	if m.isDryRun(gameServer) {
+		m.logger.Info("Dry-run, leaving release installed", zap.String("ReleaseName", releaseName))
+
+		if err := m.deleteConfigMaps(ctx, gameServer, dryRunConfigMapName(gameServer)); err != nil {
+			m.logger.Error("Failed to delete dry-run ConfigMap", zap.String("Name", gameServer.Name), zap.Error(err))
+		}
+
+		return m.removeFinalizer(ctx, gameServer)
+	}
 
-	result, err := uninstaller.Run(releaseName)
+	actionConfig, err := m.newActionConfig(namespace, m.storageDriver(gameServer))
 	if err != nil {
-		m.logger.Error("Failed run helm uninstall", zap.Error(err))
+		return err
 	}
 
-	err = m.instanceMap.Delete(releaseName)
+	// wait for an install or upgrade to finish
+	unlock, err := m.waitForRelease(ctx, namespace, releaseName)
 	if err != nil {
-		m.logger.Error("Failed to add instance to internal cache", zap.Error(err))
+		return err
 	}
+	defer unlock()
 
-	if result.Info != "" {
-		m.logger.Info(result.Info)
+	found, err := liveRelease(actionConfig, releaseName)
+	if err != nil {
+		return err
 	}
 
-	m.logger.Info("Successful Uninstall", zap.String("ReleaseName", releaseName))
+	if found == nil {
+		m.logger.Info("No release to uninstall", zap.String("ReleaseName", releaseName))
 
-	return nil
+		if err := m.instanceMap.Delete(namespace, gameServer.Name); err != nil {
+			m.logger.Error("Failed to remove instance from internal cache", zap.Error(err))
+		}
+
+		return m.removeFinalizer(ctx, gameServer)
+	}
+
+	// releases that were never adopted belong to whoever installed them, and
+	// ones deployed for another GameServer to that one
+	if !isManaged(found, gameServer) {
+		m.logger.Info("Leaving unmanaged release installed", zap.String("ReleaseName", releaseName))
+
+		if err := m.instanceMap.Delete(namespace, gameServer.Name); err != nil {
+			m.logger.Error("Failed to remove instance from internal cache", zap.Error(err))
+		}
+
+		return m.removeFinalizer(ctx, gameServer)
+	}
+
+	policy := deletionPolicy(gameServer)
+	owner := types.NamespacedName{Namespace: namespace, Name: gameServer.Name}
+
+	// the game type, shutdown and RCON settings may come from the template
+	merged := *gameServer
+	if policy != DeletionPolicyOrphan {
+		if err := m.applyTemplate(ctx, &merged, specObject(crdObject)); err != nil {
+			m.logger.Error("Failed to apply template", zap.String("Name", gameServer.Name), zap.Error(err))
+		}
+	}
+
+	// snapshots are cut while the game holds off writing, uninstall stops
+	// it after them
+	if policy != DeletionPolicyOrphan && policy != DeletionPolicySnapshot {
+		m.stopGame(ctx, &merged, "shutting down")
+	}
+
+	err = m.uninstall(ctx, actionConfig, releaseName, owner, &merged, policy)
+	if err != nil {
+		m.logger.Error("Failed run helm uninstall", zap.String("DeletionPolicy", policy), zap.Error(err))
+		m.recordEvent(ctx, &merged, EventTypeWarning, "UninstallFailed",
+			fmt.Sprintf("Release %s left installed: %v", releaseName, err))
+
+		return err
+	}
+
+	m.forgetRelease(namespace, releaseName)
+
+	// orphaned releases keep running with their RCON password and the
+	// ConfigMaps they mount
+	if policy != DeletionPolicyOrphan {
+		if err := m.deleteRCONSecret(ctx, &merged); err != nil {
+			m.logger.Error("Failed to delete RCON Secret", zap.String("Name", gameServer.Name), zap.Error(err))
+		}
+
+		if err := m.deleteMountedConfigMaps(ctx, gameServer); err != nil {
+			m.logger.Error("Failed to delete config ConfigMaps", zap.String("Name", gameServer.Name), zap.Error(err))
+		}
+	}
+
+	err = m.instanceMap.Delete(namespace, gameServer.Name)
+	if err != nil {
+		m.logger.Error("Failed to add instance to internal cache", zap.Error(err))
+	}
+
+	m.logger.Info("Successful Uninstall",
+		zap.String("ReleaseName", releaseName),
+		zap.String("DeletionPolicy", policy))
+
+	return m.removeFinalizer(ctx, gameServer)
 }
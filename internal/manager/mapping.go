@@ -0,0 +1,297 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+// valueMappingFile is the file a chart declares its value mapping in.
+const valueMappingFile = "value-mapping.yaml"
+
+// valueMapping maps typed GameServer spec fields, such as persistence.size,
+// to the values paths a chart reads them from, such as persistence.size.
+// Fields without an entry are not passed to the chart.
+type valueMapping map[string]string
+
+// rconPasswordField maps the RCON password the operator generates, rather
+// than a spec field.
+const rconPasswordField = "rcon.password"
+
+// chartValueMapping reads the value mapping a chart ships with. Charts without
+// one get an empty mapping.
+func chartValueMapping(chrt *chart.Chart) (valueMapping, error) {
+	mapping := make(valueMapping)
+
+	for _, file := range chrt.Files {
+		if file.Name != valueMappingFile {
+			continue
+		}
+
+		if err := yaml.Unmarshal(file.Data, &mapping); err != nil {
+			return nil, fmt.Errorf("failed to parse %s of chart %s: %w", valueMappingFile, chrt.Name(), err)
+		}
+	}
+
+	if err := mapping.validate(); err != nil {
+		return nil, fmt.Errorf("%s of chart %s: %w", valueMappingFile, chrt.Name(), err)
+	}
+
+	return mapping, nil
+}
+
+// validate checks every mapped field is one the operator knows how to read.
+func (vm valueMapping) validate() error {
+	for field := range vm {
+		if _, ok := specFields[field]; !ok && field != rconPasswordField {
+			return fmt.Errorf("value mapping refers to unknown field %q", field)
+		}
+	}
+
+	return nil
+}
+
+// specFields lists the typed spec fields a value mapping can refer to, and
+// how to read each of them. ok is false when the field is not set.
+var specFields = map[string]func(spec *crds.GameServerSpec) (value any, ok bool){
+	"resources": func(spec *crds.GameServerSpec) (any, bool) {
+		resources := make(map[string]any)
+		if requests := resourceListValues(spec.Resources.Requests); len(requests) > 0 {
+			resources["requests"] = requests
+		}
+
+		if limits := resourceListValues(spec.Resources.Limits); len(limits) > 0 {
+			resources["limits"] = limits
+		}
+
+		return resources, len(resources) > 0
+	},
+	"resources.requests.cpu":              resourceField(true, func(r *crds.ResourceList) string { return r.CPU }),
+	"resources.requests.memory":           resourceField(true, func(r *crds.ResourceList) string { return r.Memory }),
+	"resources.requests.ephemeralStorage": resourceField(true, func(r *crds.ResourceList) string { return r.EphemeralStorage }),
+	"resources.limits.cpu":                resourceField(false, func(r *crds.ResourceList) string { return r.CPU }),
+	"resources.limits.memory":             resourceField(false, func(r *crds.ResourceList) string { return r.Memory }),
+	"resources.limits.ephemeralStorage":   resourceField(false, func(r *crds.ResourceList) string { return r.EphemeralStorage }),
+	"persistence.enabled": func(spec *crds.GameServerSpec) (any, bool) {
+		if spec.Persistence == nil {
+			return nil, false
+		}
+
+		return spec.Persistence.Enabled, true
+	},
+	"persistence.size": func(spec *crds.GameServerSpec) (any, bool) {
+		if spec.Persistence == nil {
+			return nil, false
+		}
+
+		return spec.Persistence.Size, spec.Persistence.Size != ""
+	},
+	"persistence.storageClass": func(spec *crds.GameServerSpec) (any, bool) {
+		if spec.Persistence == nil {
+			return nil, false
+		}
+
+		return spec.Persistence.StorageClass, spec.Persistence.StorageClass != ""
+	},
+	"networking.type": func(spec *crds.GameServerSpec) (any, bool) {
+		if spec.Networking == nil {
+			return nil, false
+		}
+
+		return spec.Networking.Type, spec.Networking.Type != ""
+	},
+	"networking.annotations": func(spec *crds.GameServerSpec) (any, bool) {
+		if spec.Networking == nil || len(spec.Networking.Annotations) == 0 {
+			return nil, false
+		}
+
+		annotations := make(map[string]any, len(spec.Networking.Annotations))
+		for key, value := range spec.Networking.Annotations {
+			annotations[key] = value
+		}
+
+		return annotations, true
+	},
+	// networking.port and networking.nodePort are the first port, for charts
+	// that expose a single one
+	"networking.port": func(spec *crds.GameServerSpec) (any, bool) {
+		if spec.Networking == nil || len(spec.Networking.Ports) == 0 {
+			return nil, false
+		}
+
+		return int64(spec.Networking.Ports[0].Port), true
+	},
+	"networking.nodePort": func(spec *crds.GameServerSpec) (any, bool) {
+		if spec.Networking == nil || len(spec.Networking.Ports) == 0 || spec.Networking.Ports[0].NodePort == 0 {
+			return nil, false
+		}
+
+		return int64(spec.Networking.Ports[0].NodePort), true
+	},
+	"networking.ports": func(spec *crds.GameServerSpec) (any, bool) {
+		if spec.Networking == nil || len(spec.Networking.Ports) == 0 {
+			return nil, false
+		}
+
+		ports := make([]any, 0, len(spec.Networking.Ports))
+		for _, port := range spec.Networking.Ports {
+			ports = append(ports, portValues(port))
+		}
+
+		return ports, true
+	},
+	"players.allowlist": playersField(func(players *crds.PlayersConfig) []string { return players.Allowlist }),
+	"players.ops":       playersField(func(players *crds.PlayersConfig) []string { return players.Ops }),
+	"players.bans":      playersField(func(players *crds.PlayersConfig) []string { return players.Bans }),
+	"gameConfig": func(spec *crds.GameServerSpec) (any, bool) {
+		if len(spec.GameConfig) == 0 {
+			return nil, false
+		}
+
+		return mergeValues(make(map[string]any, len(spec.GameConfig)), spec.GameConfig), true
+	},
+}
+
+// mapSpecValues translates the typed spec fields covered by mapping into chart
+// values, one layer per field. userValues are the values set explicitly
+// through valuesFrom and valuesOverride: a mapped path they set to something
+// else is reported as a conflict, and left to them.
+func mapSpecValues(mapping valueMapping, spec *crds.GameServerSpec, userValues map[string]any) ([]valuesLayer, []string, error) {
+	layers := []valuesLayer{}
+	conflicts := []string{}
+
+	fields := make([]string, 0, len(mapping))
+	for field := range mapping {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		getter, ok := specFields[field]
+		if !ok {
+			continue
+		}
+
+		value, ok := getter(spec)
+		if !ok {
+			continue
+		}
+
+		path := mapping[field]
+
+		if userValue, found := getValuePath(userValues, path); found {
+			if !sameValue(userValue, value) {
+				conflicts = append(conflicts, fmt.Sprintf("spec.%s conflicts with %s set in chart values", field, path))
+			}
+
+			continue
+		}
+
+		mapped := make(map[string]any)
+		if err := setValuePath(mapped, path, value); err != nil {
+			return nil, nil, fmt.Errorf("failed to map spec.%s: %w", field, err)
+		}
+
+		layers = append(layers, valuesLayer{source: "spec." + field, values: mapped})
+	}
+
+	return layers, conflicts, nil
+}
+
+func resourceField(requests bool, get func(*crds.ResourceList) string) func(*crds.GameServerSpec) (any, bool) {
+	return func(spec *crds.GameServerSpec) (any, bool) {
+		list := spec.Resources.Limits
+		if requests {
+			list = spec.Resources.Requests
+		}
+
+		if list == nil {
+			return nil, false
+		}
+
+		value := get(list)
+
+		return value, value != ""
+	}
+}
+
+func playersField(get func(*crds.PlayersConfig) []string) func(*crds.GameServerSpec) (any, bool) {
+	return func(spec *crds.GameServerSpec) (any, bool) {
+		if spec.Players == nil || get(spec.Players) == nil {
+			return nil, false
+		}
+
+		names := make([]any, 0, len(get(spec.Players)))
+		for _, name := range get(spec.Players) {
+			names = append(names, name)
+		}
+
+		return names, true
+	}
+}
+
+// resourceListValues renders a ResourceList the way a container's resources
+// block spells it.
+func resourceListValues(list *crds.ResourceList) map[string]any {
+	values := make(map[string]any)
+	if list == nil {
+		return values
+	}
+
+	if list.CPU != "" {
+		values["cpu"] = list.CPU
+	}
+
+	if list.Memory != "" {
+		values["memory"] = list.Memory
+	}
+
+	if list.EphemeralStorage != "" {
+		values["ephemeral-storage"] = list.EphemeralStorage
+	}
+
+	return values
+}
+
+func portValues(port crds.PortConfig) map[string]any {
+	values := map[string]any{"port": int64(port.Port)}
+
+	if port.Name != "" {
+		values["name"] = port.Name
+	}
+
+	if port.TargetPort != 0 {
+		values["targetPort"] = int64(port.TargetPort)
+	}
+
+	if port.Protocol != "" {
+		values["protocol"] = port.Protocol
+	}
+
+	if port.NodePort != 0 {
+		values["nodePort"] = int64(port.NodePort)
+	}
+
+	return values
+}
+
+// getValuePath reads the value at a dot separated path.
+func getValuePath(values map[string]any, path string) (any, bool) {
+	return lookupValue(values, strings.Split(path, "."))
+}
+
+// sameValue compares values by their JSON form, so that numbers parsed from
+// YAML match the ones read from the spec.
+func sameValue(a, b any) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+
+	return errA == nil && errB == nil && string(aJSON) == string(bJSON)
+}
@@ -0,0 +1,81 @@
+package manager
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+func TestMapSpecValues(t *testing.T) {
+	mapping := valueMapping{
+		"resources.limits.memory": "server.memory",
+		"persistence.enabled":     "persistence.enabled",
+		"persistence.size":        "persistence.size",
+		"networking.type":         "service.type",
+		"networking.port":         "service.port",
+		"networking.nodePort":     "service.nodePort",
+	}
+
+	spec := &crds.GameServerSpec{
+		Resources:   crds.ResourceRequirements{Limits: &crds.ResourceList{Memory: "4Gi"}},
+		Persistence: &crds.PersistenceConfig{Enabled: false, Size: "10Gi"},
+		Networking: &crds.NetworkingConfig{
+			Type:  "NodePort",
+			Ports: []crds.PortConfig{{Port: 25565}},
+		},
+	}
+
+	layers, conflicts, err := mapSpecValues(mapping, spec, map[string]any{
+		// the same value isn't a conflict
+		"service": map[string]any{"type": "NodePort", "port": 25566},
+	})
+	if err != nil {
+		t.Fatalf("mapSpecValues: %v", err)
+	}
+
+	sources := make([]string, 0, len(layers))
+	for _, layer := range layers {
+		sources = append(sources, layer.source)
+	}
+
+	// fields in order, without those the user values set or the spec leaves
+	// unset
+	wantSources := []string{"spec.persistence.enabled", "spec.persistence.size", "spec.resources.limits.memory"}
+	if !reflect.DeepEqual(sources, wantSources) {
+		t.Errorf("layers from %v, want %v", sources, wantSources)
+	}
+
+	if want := map[string]any{"persistence": map[string]any{"enabled": false}}; !reflect.DeepEqual(layers[0].values, want) {
+		t.Errorf("persistence.enabled layer = %v, want %v", layers[0].values, want)
+	}
+
+	if want := map[string]any{"server": map[string]any{"memory": "4Gi"}}; !reflect.DeepEqual(layers[2].values, want) {
+		t.Errorf("resources.limits.memory layer = %v, want %v", layers[2].values, want)
+	}
+
+	wantConflicts := []string{"spec.networking.port conflicts with service.port set in chart values"}
+	if !reflect.DeepEqual(conflicts, wantConflicts) {
+		t.Errorf("conflicts = %v, want %v", conflicts, wantConflicts)
+	}
+}
+
+func TestValueMappingValidate(t *testing.T) {
+	tests := map[string]struct {
+		mapping valueMapping
+		valid   bool
+	}{
+		"spec fields":   {valueMapping{"resources": "resources", "persistence.size": "storage.size"}, true},
+		"rcon password": {valueMapping{rconPasswordField: "server.rcon.password"}, true},
+		"empty":         {valueMapping{}, true},
+		"unknown field": {valueMapping{"persistence.sizee": "persistence.size"}, false},
+		"whole spec":    {valueMapping{"persistence": "persistence"}, false},
+	}
+
+	for name, test := range tests {
+		err := test.mapping.validate()
+		if (err == nil) != test.valid {
+			t.Errorf("%s: validate = %v, want valid %v", name, err, test.valid)
+		}
+	}
+}
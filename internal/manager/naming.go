@@ -0,0 +1,79 @@
+package manager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+// Release naming strategies, see Options.ReleaseNaming.
+const (
+	// ReleaseNamingName names releases after their GameServer
+	ReleaseNamingName = "name"
+	// ReleaseNamingNameHash names releases after their GameServer, shortened
+	// to fit Helm's limit and suffixed with a hash of its namespace and name
+	ReleaseNamingNameHash = "name-hash"
+)
+
+// maxReleaseNameLength is Helm's limit on release names.
+const maxReleaseNameLength = 53
+
+// releaseNameHashLength is the length of the hash suffix of name-hash names.
+const releaseNameHashLength = 8
+
+func validReleaseNaming(strategy string) error {
+	switch strategy {
+	case "", ReleaseNamingName, ReleaseNamingNameHash:
+		return nil
+	default:
+		return fmt.Errorf("unknown release naming strategy %q", strategy)
+	}
+}
+
+// ReleaseName returns the name of a GameServer's Helm release. Once a release
+// is deployed its name is kept in status.helmRelease.name, and sticks even if
+// the naming strategy changes. Before that, spec.helmChart.releaseName wins
+// over the strategy.
+func (m *Manager) ReleaseName(gameServer *crds.GameServer) (string, error) {
+	explicit := gameServer.Spec.HelmChart.ReleaseName
+
+	if deployed := gameServer.Status.HelmRelease; deployed != nil && deployed.Name != "" {
+		if explicit != "" && explicit != deployed.Name {
+			return "", fmt.Errorf("spec.helmChart.releaseName %q can't replace deployed release %s", explicit, deployed.Name)
+		}
+
+		return deployed.Name, nil
+	}
+
+	name := explicit
+	if name == "" {
+		name = m.strategyReleaseName(gameServer)
+	}
+
+	if err := chartutil.ValidateReleaseName(name); err != nil {
+		return "", fmt.Errorf("invalid release name %q: %w", name, err)
+	}
+
+	return name, nil
+}
+
+func (m *Manager) strategyReleaseName(gameServer *crds.GameServer) string {
+	if m.options.ReleaseNaming != ReleaseNamingNameHash {
+		return gameServer.Name
+	}
+
+	sum := sha256.Sum256([]byte(gameServer.Namespace + "/" + gameServer.Name))
+	suffix := hex.EncodeToString(sum[:])[:releaseNameHashLength]
+
+	prefix := gameServer.Name
+	if limit := maxReleaseNameLength - releaseNameHashLength - 1; len(prefix) > limit {
+		prefix = prefix[:limit]
+	}
+
+	return strings.TrimRight(prefix, "-.") + "-" + suffix
+}
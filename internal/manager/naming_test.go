@@ -0,0 +1,90 @@
+package manager
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+func TestReleaseName(t *testing.T) {
+	long := strings.Repeat("survival-", 7)
+
+	hashed := (&Manager{options: Options{ReleaseNaming: ReleaseNamingNameHash}}).strategyReleaseName(
+		&crds.GameServer{ObjectMeta: metav1.ObjectMeta{Namespace: "games", Name: "survival"}})
+
+	tests := []struct {
+		name      string
+		strategy  string
+		gsName    string
+		namespace string
+		explicit  string
+		deployed  string
+		want      string
+		wantErr   bool
+	}{
+		{name: "default strategy", gsName: "survival", want: "survival"},
+		{name: "name strategy", strategy: ReleaseNamingName, gsName: "survival", want: "survival"},
+		{name: "name-hash", strategy: ReleaseNamingNameHash, gsName: "survival", namespace: "games", want: hashed},
+		{name: "explicit", strategy: ReleaseNamingNameHash, gsName: "survival", explicit: "smp", want: "smp"},
+		{name: "deployed sticks", strategy: ReleaseNamingNameHash, gsName: "survival", deployed: "survival", want: "survival"},
+		{name: "explicit matches deployed", gsName: "survival", explicit: "smp", deployed: "smp", want: "smp"},
+		{name: "explicit renames deployed", gsName: "survival", explicit: "smp", deployed: "survival", wantErr: true},
+		{name: "too long", gsName: long, wantErr: true},
+		{name: "invalid explicit", gsName: "survival", explicit: "Survival_1", wantErr: true},
+	}
+
+	for _, test := range tests {
+		m := &Manager{options: Options{ReleaseNaming: test.strategy}}
+
+		gameServer := &crds.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Namespace: test.namespace, Name: test.gsName},
+			Spec:       crds.GameServerSpec{HelmChart: crds.HelmChart{ReleaseName: test.explicit}},
+		}
+
+		if test.deployed != "" {
+			gameServer.Status.HelmRelease = &crds.HelmReleaseStatus{Name: test.deployed}
+		}
+
+		got, err := m.ReleaseName(gameServer)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: ReleaseName error = %v, want error %v", test.name, err, test.wantErr)
+
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("%s: ReleaseName = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestStrategyReleaseNameHash(t *testing.T) {
+	m := &Manager{options: Options{ReleaseNaming: ReleaseNamingNameHash}}
+
+	name := func(namespace, gsName string) string {
+		return m.strategyReleaseName(&crds.GameServer{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: gsName}})
+	}
+
+	if name("games", "survival") == name("lobby", "survival") {
+		t.Error("GameServers of the same name in different namespaces share a release name")
+	}
+
+	if !strings.HasPrefix(name("games", "survival"), "survival-") {
+		t.Errorf("release name %q doesn't start with the GameServer's name", name("games", "survival"))
+	}
+
+	// the prefix is cut to fit, without leaving a separator before the hash
+	long := strings.Repeat("a", 43) + "-" + strings.Repeat("b", 20)
+
+	got := name("games", long)
+	if len(got) > maxReleaseNameLength {
+		t.Errorf("release name %q is longer than %d", got, maxReleaseNameLength)
+	}
+
+	if strings.Contains(got, "--") {
+		t.Errorf("release name %q keeps the cut separator", got)
+	}
+}
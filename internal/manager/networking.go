@@ -0,0 +1,219 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+// externalDNSHostnameAnnotation is the hostname external-dns publishes for a
+// Service. Players connect to it rather than to the address behind it.
+const externalDNSHostnameAnnotation = "external-dns.alpha.kubernetes.io/hostname"
+
+var (
+	serviceResource = schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	nodeResource    = schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+)
+
+// observeServices describes the Services among objects, and returns where
+// players connect to. The address is empty while no Service is reachable from
+// outside the cluster.
+func (m *Manager) observeServices(ctx context.Context, namespace string, objects []releaseObject) (*crds.NetworkingStatus, string, error) {
+	var services []corev1.Service
+
+	for _, object := range objects {
+		if object.kind != "Service" {
+			continue
+		}
+
+		obj, err := m.k8sClient.Resource(serviceResource).Namespace(namespace).Get(ctx, object.name, metav1.GetOptions{})
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get Service %s: %w", object.name, err)
+		}
+
+		var service corev1.Service
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &service); err != nil {
+			return nil, "", err
+		}
+
+		services = append(services, service)
+	}
+
+	if len(services) == 0 {
+		return nil, "", nil
+	}
+
+	networking := &crds.NetworkingStatus{}
+
+	for _, service := range services {
+		networking.Services = append(networking.Services, crds.ServiceStatus{
+			Name:              service.Name,
+			Type:              string(service.Spec.Type),
+			ClusterIP:         service.Spec.ClusterIP,
+			ExternalAddresses: externalAddresses(service),
+			Ports:             servicePorts(service),
+		})
+	}
+
+	primary := primaryService(services)
+
+	networking.ServiceType = string(primary.Spec.Type)
+	networking.ClusterIP = primary.Spec.ClusterIP
+	networking.Ports = servicePorts(primary)
+
+	for _, ingress := range primary.Status.LoadBalancer.Ingress {
+		if networking.ExternalIP == "" && ingress.IP != "" {
+			networking.ExternalIP = ingress.IP
+		}
+
+		if networking.ExternalHostname == "" && ingress.Hostname != "" {
+			networking.ExternalHostname = ingress.Hostname
+		}
+	}
+
+	connectAddress, err := m.connectAddress(ctx, primary)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return networking, connectAddress, nil
+}
+
+// primaryService picks the Service players connect to, the most exposed one,
+// and the first of those in manifest order.
+func primaryService(services []corev1.Service) corev1.Service {
+	exposure := map[corev1.ServiceType]int{
+		corev1.ServiceTypeLoadBalancer: 3,
+		corev1.ServiceTypeNodePort:     2,
+		corev1.ServiceTypeClusterIP:    1,
+	}
+
+	primary := services[0]
+
+	for _, service := range services[1:] {
+		// headless Services only exist for StatefulSet pod DNS
+		if service.Spec.ClusterIP == corev1.ClusterIPNone {
+			continue
+		}
+
+		if primary.Spec.ClusterIP == corev1.ClusterIPNone || exposure[service.Spec.Type] > exposure[primary.Spec.Type] {
+			primary = service
+		}
+	}
+
+	return primary
+}
+
+// connectAddress returns host:port of the first port of a Service, as seen
+// from outside the cluster.
+func (m *Manager) connectAddress(ctx context.Context, service corev1.Service) (string, error) {
+	if len(service.Spec.Ports) == 0 {
+		return "", nil
+	}
+
+	port := service.Spec.Ports[0]
+
+	if hostname := service.Annotations[externalDNSHostnameAnnotation]; hostname != "" {
+		return net.JoinHostPort(hostname, strconv.Itoa(int(port.Port))), nil
+	}
+
+	if addresses := externalAddresses(service); len(addresses) > 0 {
+		return net.JoinHostPort(addresses[0], strconv.Itoa(int(port.Port))), nil
+	}
+
+	if service.Spec.Type == corev1.ServiceTypeNodePort && port.NodePort != 0 {
+		nodeAddress, err := m.nodeAddress(ctx)
+		if err != nil || nodeAddress == "" {
+			return "", err
+		}
+
+		return net.JoinHostPort(nodeAddress, strconv.Itoa(int(port.NodePort))), nil
+	}
+
+	return "", nil
+}
+
+// nodeAddress returns the external address of a ready node, or its internal
+// address when no node has an external one.
+func (m *Manager) nodeAddress(ctx context.Context) (string, error) {
+	list, err := m.k8sClient.Resource(nodeResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	var internal string
+
+	for _, item := range list.Items {
+		var node corev1.Node
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &node); err != nil {
+			return "", err
+		}
+
+		if !nodeReady(node) {
+			continue
+		}
+
+		for _, address := range node.Status.Addresses {
+			switch address.Type {
+			case corev1.NodeExternalIP, corev1.NodeExternalDNS:
+				return address.Address, nil
+			case corev1.NodeInternalIP:
+				if internal == "" {
+					internal = address.Address
+				}
+			}
+		}
+	}
+
+	return internal, nil
+}
+
+func nodeReady(node corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+// externalAddresses returns the LoadBalancer ingress hostnames and IPs of a
+// Service, then its spec.externalIPs.
+func externalAddresses(service corev1.Service) []string {
+	var addresses []string
+
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.Hostname != "" {
+			addresses = append(addresses, ingress.Hostname)
+		} else if ingress.IP != "" {
+			addresses = append(addresses, ingress.IP)
+		}
+	}
+
+	return append(addresses, service.Spec.ExternalIPs...)
+}
+
+func servicePorts(service corev1.Service) []crds.PortStatus {
+	ports := make([]crds.PortStatus, 0, len(service.Spec.Ports))
+
+	for _, port := range service.Spec.Ports {
+		ports = append(ports, crds.PortStatus{
+			Name:       port.Name,
+			Port:       port.Port,
+			TargetPort: port.TargetPort.IntVal,
+			NodePort:   port.NodePort,
+			Protocol:   string(port.Protocol),
+		})
+	}
+
+	return ports
+}
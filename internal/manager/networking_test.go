@@ -0,0 +1,133 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func testService(name string, serviceType corev1.ServiceType, clusterIP string) corev1.Service {
+	return corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.ServiceSpec{
+			Type:      serviceType,
+			ClusterIP: clusterIP,
+			Ports:     []corev1.ServicePort{{Port: 25565, NodePort: 30565}},
+		},
+	}
+}
+
+func TestPrimaryService(t *testing.T) {
+	headless := testService("headless", corev1.ServiceTypeClusterIP, corev1.ClusterIPNone)
+	internal := testService("internal", corev1.ServiceTypeClusterIP, "10.0.0.1")
+	nodePort := testService("node-port", corev1.ServiceTypeNodePort, "10.0.0.2")
+	loadBalancer := testService("load-balancer", corev1.ServiceTypeLoadBalancer, "10.0.0.3")
+	rcon := testService("rcon", corev1.ServiceTypeLoadBalancer, "10.0.0.4")
+
+	tests := []struct {
+		name     string
+		services []corev1.Service
+		want     string
+	}{
+		{"only one", []corev1.Service{internal}, "internal"},
+		{"most exposed", []corev1.Service{internal, loadBalancer, nodePort}, "load-balancer"},
+		{"first of equals", []corev1.Service{loadBalancer, rcon}, "load-balancer"},
+		{"headless skipped", []corev1.Service{headless, internal}, "internal"},
+		{"headless never replaces", []corev1.Service{internal, headless}, "internal"},
+		{"only headless", []corev1.Service{headless}, "headless"},
+	}
+
+	for _, test := range tests {
+		if got := primaryService(test.services); got.Name != test.want {
+			t.Errorf("%s: primaryService = %s, want %s", test.name, got.Name, test.want)
+		}
+	}
+}
+
+func TestConnectAddress(t *testing.T) {
+	node := func(name string, ready corev1.ConditionStatus, addresses ...corev1.NodeAddress) *unstructured.Unstructured {
+		obj := toObject(t, "v1", "Node", name, &corev1.Node{Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}},
+			Addresses:  addresses,
+		}})
+		obj.SetNamespace("")
+
+		return obj
+	}
+
+	internalIP := func(ip string) corev1.NodeAddress {
+		return corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: ip}
+	}
+	externalIP := func(ip string) corev1.NodeAddress {
+		return corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: ip}
+	}
+
+	annotated := testService("annotated", corev1.ServiceTypeLoadBalancer, "10.0.0.1")
+	annotated.Annotations = map[string]string{externalDNSHostnameAnnotation: "mc.example.com"}
+	annotated.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "203.0.113.1"}}
+
+	loadBalancer := testService("load-balancer", corev1.ServiceTypeLoadBalancer, "10.0.0.1")
+	loadBalancer.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "lb.example.com", IP: "203.0.113.1"}}
+
+	externalIPs := testService("external-ips", corev1.ServiceTypeClusterIP, "10.0.0.1")
+	externalIPs.Spec.ExternalIPs = []string{"198.51.100.7"}
+
+	pending := testService("pending", corev1.ServiceTypeLoadBalancer, "10.0.0.1")
+	nodePort := testService("node-port", corev1.ServiceTypeNodePort, "10.0.0.1")
+	internal := testService("internal", corev1.ServiceTypeClusterIP, "10.0.0.1")
+
+	noPorts := testService("no-ports", corev1.ServiceTypeLoadBalancer, "10.0.0.1")
+	noPorts.Spec.Ports = nil
+
+	tests := []struct {
+		name    string
+		service corev1.Service
+		nodes   []*unstructured.Unstructured
+		want    string
+	}{
+		{name: "external-dns hostname", service: annotated, want: "mc.example.com:25565"},
+		{name: "load balancer hostname", service: loadBalancer, want: "lb.example.com:25565"},
+		{name: "external IPs", service: externalIPs, want: "198.51.100.7:25565"},
+		{name: "load balancer pending", service: pending},
+		{name: "cluster internal", service: internal},
+		{name: "no ports", service: noPorts},
+		{
+			name:    "node external address",
+			service: nodePort,
+			nodes: []*unstructured.Unstructured{
+				node("a", corev1.ConditionTrue, internalIP("10.1.0.1")),
+				node("b", corev1.ConditionTrue, internalIP("10.1.0.2"), externalIP("203.0.113.9")),
+			},
+			want: "203.0.113.9:30565",
+		},
+		{
+			name:    "node internal address",
+			service: nodePort,
+			nodes:   []*unstructured.Unstructured{node("a", corev1.ConditionTrue, internalIP("10.1.0.1"))},
+			want:    "10.1.0.1:30565",
+		},
+		{
+			name:    "nodes not ready",
+			service: nodePort,
+			nodes:   []*unstructured.Unstructured{node("a", corev1.ConditionFalse, externalIP("203.0.113.9"))},
+		},
+	}
+
+	for _, test := range tests {
+		m, _ := newFakeManager(t, test.nodes...)
+
+		got, err := m.connectAddress(context.Background(), test.service)
+		if err != nil {
+			t.Errorf("%s: connectAddress: %v", test.name, err)
+
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("%s: connectAddress = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package manager
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+	"github.com/Sackbuoy/gameserver-operator/internal/games"
+)
+
+const (
+	// rotateRCONAnnotation rotates a generated RCON password each time it is
+	// set to a new value
+	rotateRCONAnnotation = "goopy.us/rotate-rcon"
+
+	// restartedAtAnnotation is set on the pod template of workloads to roll
+	// them, like kubectl rollout restart does
+	restartedAtAnnotation = "goopy.us/restarted-at"
+)
+
+// rconPasswordBytes is how much randomness a generated password holds.
+const rconPasswordBytes = 24
+
+// rconPasswordPath returns the values path a GameServer's chart reads the RCON
+// password from: the one mapped to rcon.password, or else the one the game's
+// driver knows. ok is false for games without a console or a known path.
+func rconPasswordPath(gameServer *crds.GameServer, mapping valueMapping) (string, bool) {
+	if _, err := consolePort(gameServer); err != nil {
+		return "", false
+	}
+
+	if path, ok := mapping[rconPasswordField]; ok {
+		return path, true
+	}
+
+	if driver, ok := games.Lookup(gameServer.Spec.GameType).(games.RCONValues); ok {
+		return driver.RCONPasswordPath(), true
+	}
+
+	return "", false
+}
+
+// rconPasswordLayer sets the RCON password of a GameServer in the chart
+// values, generating it on first use. It is nil when the chart has no place
+// for it, or when userValues already set one.
+func (m *Manager) rconPasswordLayer(ctx context.Context, gameServer *crds.GameServer, mapping valueMapping, userValues map[string]any) (*valuesLayer, error) {
+	path, ok := rconPasswordPath(gameServer, mapping)
+	if !ok {
+		return nil, nil
+	}
+
+	if _, found := getValuePath(userValues, path); found {
+		return nil, nil
+	}
+
+	password, err := m.ensureRCONPassword(ctx, gameServer)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]any)
+	if err := setValuePath(values, path, password); err != nil {
+		return nil, fmt.Errorf("failed to map %s: %w", rconPasswordField, err)
+	}
+
+	return &valuesLayer{
+		source:    fmt.Sprintf("RCON Secret %s/%s", gameServer.Namespace, rconSecretName(gameServer)),
+		values:    values,
+		sensitive: true,
+	}, nil
+}
+
+// ensureRCONPassword reads the RCON password of a GameServer, generating the
+// default Secret when it doesn't exist yet. A Secret named in
+// spec.rcon.passwordSecret is never generated.
+func (m *Manager) ensureRCONPassword(ctx context.Context, gameServer *crds.GameServer) (string, error) {
+	name := rconSecretName(gameServer)
+
+	password, found, err := m.readValuesReference(ctx, gameServer.Namespace, "Secret", name, rconPasswordKey)
+	if err != nil || found {
+		return password, err
+	}
+
+	if gameServer.Spec.RCON != nil && gameServer.Spec.RCON.PasswordSecret != "" {
+		return "", fmt.Errorf("secret %s/%s has no %s", gameServer.Namespace, name, rconPasswordKey)
+	}
+
+	password, err = generateRCONPassword()
+	if err != nil {
+		return "", err
+	}
+
+	err = m.createRCONSecret(ctx, gameServer, name, password)
+	if apierrors.IsAlreadyExists(err) {
+		// created since it was read, or without a password
+		password, found, err = m.readValuesReference(ctx, gameServer.Namespace, "Secret", name, rconPasswordKey)
+		if err == nil && !found {
+			err = fmt.Errorf("secret %s/%s has no %s", gameServer.Namespace, name, rconPasswordKey)
+		}
+
+		return password, err
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to create Secret %s/%s: %w", gameServer.Namespace, name, err)
+	}
+
+	m.logger.Info("Generated RCON password", zap.String("Name", gameServer.Name), zap.String("Secret", name))
+	m.recordEvent(ctx, gameServer, EventTypeNormal, "RCONPasswordGenerated",
+		fmt.Sprintf("Generated an RCON password in Secret %s", name))
+
+	return password, nil
+}
+
+// createRCONSecret creates the Secret of a generated RCON password. It isn't
+// owned by the GameServer, so that the game can still be stopped over RCON
+// while the GameServer is deleted; Delete removes it.
+func (m *Manager) createRCONSecret(ctx context.Context, gameServer *crds.GameServer, name, password string) error {
+	secret := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]any{
+			"name":      name,
+			"namespace": gameServer.Namespace,
+			"labels": map[string]any{
+				managedByLabel:        managedByValue,
+				"goopy.us/gameserver": gameServer.Name,
+			},
+		},
+		"type": "Opaque",
+		"data": map[string]any{
+			rconPasswordKey: base64.StdEncoding.EncodeToString([]byte(password)),
+		},
+	}}
+
+	_, err := m.k8sClient.Resource(secretResource).Namespace(gameServer.Namespace).Create(ctx, secret, metav1.CreateOptions{})
+
+	return err
+}
+
+// generateRCONPassword returns a random password that is safe to pass
+// through environment variables and config files unquoted.
+func generateRCONPassword() (string, error) {
+	random := make([]byte, rconPasswordBytes)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate RCON password: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(random), nil
+}
+
+// rotateRCONPassword replaces the generated RCON password of a GameServer when
+// its goopy.us/rotate-rcon annotation asks for it. The game is stopped first,
+// while the old password still reaches its console. rotated reports whether
+// the caller has to roll the game's workloads onto the new password.
+func (m *Manager) rotateRCONPassword(ctx context.Context, gameServer *crds.GameServer) (bool, error) {
+	request := gameServer.Annotations[rotateRCONAnnotation]
+	if request == "" || (gameServer.Status.RCON != nil && gameServer.Status.RCON.RotationRequest == request) {
+		return false, nil
+	}
+
+	name := rconSecretName(gameServer)
+	client := m.k8sClient.Resource(secretResource).Namespace(gameServer.Namespace)
+
+	secret, err := client.Get(ctx, name, metav1.GetOptions{})
+
+	var skipped error
+
+	switch _, portErr := consolePort(gameServer); {
+	case errors.Is(portErr, errNoConsole):
+		skipped = portErr
+	case apierrors.IsNotFound(err):
+		skipped = fmt.Errorf("secret %s doesn't exist", name)
+	case err != nil:
+		return false, fmt.Errorf("failed to get Secret %s/%s: %w", gameServer.Namespace, name, err)
+	case secret.GetLabels()[managedByLabel] != managedByValue:
+		skipped = fmt.Errorf("secret %s isn't generated by the operator", name)
+	}
+
+	if skipped != nil {
+		m.recordEvent(ctx, gameServer, EventTypeWarning, "RotationSkipped",
+			fmt.Sprintf("RCON password not rotated: %v", skipped))
+
+		return false, m.recordRotation(ctx, gameServer, request, false)
+	}
+
+	m.stopGame(ctx, gameServer, "restarting to rotate its RCON password")
+
+	password, err := generateRCONPassword()
+	if err != nil {
+		return false, err
+	}
+
+	err = unstructured.SetNestedField(secret.Object, base64.StdEncoding.EncodeToString([]byte(password)), "data", rconPasswordKey)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := client.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return false, fmt.Errorf("failed to update Secret %s/%s: %w", gameServer.Namespace, name, err)
+	}
+
+	m.logger.Info("Rotated RCON password", zap.String("Name", gameServer.Name), zap.String("Secret", name))
+	m.recordEvent(ctx, gameServer, EventTypeNormal, "RCONPasswordRotated",
+		fmt.Sprintf("Rotated the RCON password in Secret %s", name))
+
+	return true, m.recordRotation(ctx, gameServer, request, true)
+}
+
+// recordRotation marks a rotation request as handled, so it isn't acted on
+// again.
+func (m *Manager) recordRotation(ctx context.Context, gameServer *crds.GameServer, request string, rotated bool) error {
+	now := metav1.Now()
+
+	err := m.updateStatus(ctx, gameServer.Namespace, gameServer.Name, func(status *crds.GameServerStatus) {
+		if status.RCON == nil {
+			status.RCON = &crds.RCONStatus{}
+		}
+
+		status.RCON.RotationRequest = request
+
+		if rotated {
+			status.RCON.LastRotated = &now
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record RCON password rotation: %w", err)
+	}
+
+	gameServer.Status.RCON = &crds.RCONStatus{RotationRequest: request}
+
+	return nil
+}
+
+// restartForRotation rolls the workloads of a release onto a rotated RCON
+// password.
+func (m *Manager) restartForRotation(ctx context.Context, gameServer *crds.GameServer, releaseName string) {
+	if err := m.restartWorkloads(ctx, gameServer, releaseName); err != nil {
+		m.logger.Error("Failed to restart after rotating RCON password", zap.String("ReleaseName", releaseName), zap.Error(err))
+		m.recordEvent(ctx, gameServer, EventTypeWarning, "RestartFailed",
+			fmt.Sprintf("Game not restarted onto its rotated RCON password: %v", err))
+	}
+}
+
+// restartWorkloads rolls the Deployments and StatefulSets of a release, for
+// changes that don't show in their pod templates.
+func (m *Manager) restartWorkloads(ctx context.Context, gameServer *crds.GameServer, releaseName string) error {
+	actionConfig, err := m.newActionConfig(gameServer.Namespace, m.storageDriver(gameServer))
+	if err != nil {
+		return err
+	}
+
+	objects, err := m.releaseObjects(actionConfig, gameServer.Namespace, releaseName)
+	if err != nil {
+		return err
+	}
+
+	patch, err := json.Marshal(map[string]any{
+		"spec": map[string]any{
+			"template": map[string]any{
+				"metadata": map[string]any{
+					"annotations": map[string]any{
+						restartedAtAnnotation: time.Now().UTC().Format(time.RFC3339),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, object := range objects {
+		resource := deploymentResource
+
+		switch object.kind {
+		case "Deployment":
+		case "StatefulSet":
+			resource = statefulSetResource
+		default:
+			continue
+		}
+
+		_, err := m.k8sClient.Resource(resource).Namespace(gameServer.Namespace).Patch(ctx, object.name, types.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to restart %s %s: %w", object.kind, object.name, err)
+		}
+	}
+
+	return nil
+}
+
+// deleteRCONSecret removes the generated RCON password of a GameServer. Secrets
+// the operator didn't generate are left alone.
+func (m *Manager) deleteRCONSecret(ctx context.Context, gameServer *crds.GameServer) error {
+	name := rconSecretName(gameServer)
+	client := m.k8sClient.Resource(secretResource).Namespace(gameServer.Namespace)
+
+	secret, err := client.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if secret.GetLabels()[managedByLabel] != managedByValue || secret.GetLabels()["goopy.us/gameserver"] != gameServer.Name {
+		return nil
+	}
+
+	err = client.Delete(ctx, name, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,66 @@
+package manager
+
+import (
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+)
+
+// renderDeployment renders the Deployment of a bundled chart with an RCON
+// password set the way the operator sets it.
+func renderDeployment(t *testing.T, chartName, password string) string {
+	t.Helper()
+
+	chrt, err := loader.Load("../../charts/" + chartName)
+	if err != nil {
+		t.Fatalf("loading chart: %v", err)
+	}
+
+	mapping, err := chartValueMapping(chrt)
+	if err != nil {
+		t.Fatalf("chartValueMapping: %v", err)
+	}
+
+	path, ok := mapping[rconPasswordField]
+	if !ok {
+		t.Fatalf("chart %s maps no %s", chartName, rconPasswordField)
+	}
+
+	values := make(map[string]any)
+	if err := setValuePath(values, path, password); err != nil {
+		t.Fatalf("mapping the password: %v", err)
+	}
+
+	renderValues, err := chartutil.ToRenderValues(chrt, values, chartutil.ReleaseOptions{Name: "test", Namespace: "default"}, nil)
+	if err != nil {
+		t.Fatalf("ToRenderValues: %v", err)
+	}
+
+	rendered, err := engine.Render(chrt, renderValues)
+	if err != nil {
+		t.Fatalf("rendering chart: %v", err)
+	}
+
+	return rendered[chartName+"/templates/deployment.yaml"]
+}
+
+func TestRotatedPasswordRollsBundledChart(t *testing.T) {
+	before := renderDeployment(t, "minecraft-java", "old-password")
+	after := renderDeployment(t, "minecraft-java", "new-password")
+
+	if strings.Contains(before, "old-password") {
+		t.Error("the Deployment holds the password in plain text")
+	}
+
+	changed, err := podTemplateChanged(before, after)
+	if err != nil {
+		t.Fatalf("podTemplateChanged: %v", err)
+	}
+
+	if !changed {
+		t.Error("rotating the password leaves the pods running on the old one")
+	}
+}
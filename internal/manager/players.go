@@ -0,0 +1,256 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+	"github.com/Sackbuoy/gameserver-operator/internal/games"
+)
+
+// ConditionPlayersSynced is True while the player lists of the game match
+// spec.players.
+const ConditionPlayersSynced = "PlayersSynced"
+
+// playersConfigMapName is the ConfigMap the player list files of a GameServer
+// are published in, for the game to seed its lists from on first boot.
+func playersConfigMapName(gameServer *crds.GameServer) string {
+	return gameServer.Name + "-players"
+}
+
+// desiredPlayers returns the lists spec.players manages.
+func desiredPlayers(gameServer *crds.GameServer) games.PlayerLists {
+	lists := make(games.PlayerLists)
+
+	players := gameServer.Spec.Players
+	if players == nil {
+		return lists
+	}
+
+	for list, names := range map[games.PlayerList][]string{
+		games.Allowlist: players.Allowlist,
+		games.Ops:       players.Ops,
+		games.Bans:      players.Bans,
+	} {
+		if names != nil {
+			lists[list] = names
+		}
+	}
+
+	return lists
+}
+
+// appliedPlayers returns the lists the operator last applied to the game.
+func appliedPlayers(gameServer *crds.GameServer) games.PlayerLists {
+	lists := make(games.PlayerLists)
+
+	players := gameServer.Status.Players
+	if players == nil {
+		return lists
+	}
+
+	lists[games.Allowlist] = players.Allowlist
+	lists[games.Ops] = players.Ops
+	lists[games.Bans] = players.Bans
+
+	return lists
+}
+
+// publishPlayerFiles renders spec.players into the files the game seeds its
+// lists from, for charts to mount. Failures are logged, the lists are synced
+// over the console once the game runs anyway.
+func (m *Manager) publishPlayerFiles(ctx context.Context, gameServer *crds.GameServer) {
+	driver, ok := games.Lookup(gameServer.Spec.GameType).(games.Players)
+	if !ok || gameServer.Spec.Players == nil {
+		return
+	}
+
+	err := m.applyConfigMap(ctx, gameServer, playersConfigMapName(gameServer), driver.PlayerFiles(desiredPlayers(gameServer)), false)
+	if err != nil {
+		m.logger.Error("Failed to publish player lists", zap.String("Name", gameServer.Name), zap.Error(err))
+	}
+}
+
+// syncPlayers brings the player lists of a running game in line with
+// spec.players over its console. Players on a listed game list but missing
+// from the spec are removed; on lists the game can't show, and on lists the
+// spec leaves out, only the players the operator added are.
+func (m *Manager) syncPlayers(ctx context.Context, gameServer *crds.GameServer) {
+	desired := desiredPlayers(gameServer)
+	applied := appliedPlayers(gameServer)
+
+	if len(desired) == 0 && gameServer.Status.Players == nil {
+		return
+	}
+
+	driver, ok := games.Lookup(gameServer.Spec.GameType).(games.Players)
+	if !ok {
+		m.setPlayersCondition(ctx, gameServer, ConditionFalse, "Unsupported",
+			fmt.Sprintf("The driver of game type %q doesn't manage players", gameServer.Spec.GameType))
+
+		return
+	}
+
+	console, err := m.console(ctx, gameServer)
+	if err != nil {
+		m.setPlayersCondition(ctx, gameServer, ConditionFalse, consoleFailureReason(err), err.Error())
+
+		return
+	}
+	defer console.Close()
+
+	live, err := driver.ListPlayers(ctx, console)
+	if err != nil {
+		m.setPlayersCondition(ctx, gameServer, ConditionFalse, "ListFailed", err.Error())
+
+		return
+	}
+
+	var (
+		drift    []string
+		failures []string
+		changes  int
+	)
+
+	result := make(games.PlayerLists)
+
+	for _, list := range games.PlayerListNames {
+		want, managed := desired[list]
+		current, listed := live[list]
+
+		if managed && listed {
+			drift = append(drift, listDrift(list, want, current)...)
+		}
+
+		if !managed || !listed {
+			current = applied[list]
+		}
+
+		var names []string
+
+		for _, name := range want {
+			if containsPlayer(current, name) {
+				names = append(names, name)
+
+				continue
+			}
+
+			if err := driver.AddPlayer(ctx, console, list, name); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", list, err))
+
+				continue
+			}
+
+			names = append(names, name)
+			changes++
+		}
+
+		for _, name := range current {
+			if containsPlayer(want, name) {
+				continue
+			}
+
+			if err := driver.RemovePlayer(ctx, console, list, name); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", list, err))
+
+				// still on the list, removing it is retried
+				names = append(names, name)
+
+				continue
+			}
+
+			changes++
+		}
+
+		result[list] = names
+	}
+
+	if changes > 0 {
+		m.logger.Info("Synced player lists", zap.String("Name", gameServer.Name), zap.Int("Changes", changes))
+		m.recordEvent(ctx, gameServer, EventTypeNormal, "PlayersSynced",
+			fmt.Sprintf("Applied %d changes to the player lists", changes))
+	}
+
+	err = m.updateStatus(ctx, gameServer.Namespace, gameServer.Name, func(status *crds.GameServerStatus) {
+		if len(desired) == 0 && len(result[games.Allowlist])+len(result[games.Ops])+len(result[games.Bans]) == 0 {
+			status.Players = nil
+			removeCondition(status, ConditionPlayersSynced)
+
+			return
+		}
+
+		players := &crds.PlayersStatus{
+			Allowlist: result[games.Allowlist],
+			Ops:       result[games.Ops],
+			Bans:      result[games.Bans],
+			Drift:     drift,
+		}
+
+		// what drifted stays on record until the next correction
+		if status.Players != nil && changes == 0 {
+			players.LastChanged = status.Players.LastChanged
+			players.Drift = status.Players.Drift
+		}
+
+		if changes > 0 {
+			now := metav1.Now()
+			players.LastChanged = &now
+		}
+
+		status.Players = players
+
+		if len(failures) > 0 {
+			setCondition(status, ConditionPlayersSynced, ConditionFalse, "SyncFailed", strings.Join(failures, "; "))
+		} else {
+			setCondition(status, ConditionPlayersSynced, ConditionTrue, "Synced", "The player lists match the spec")
+		}
+	})
+	if err != nil {
+		m.logger.Error("Failed to update GameServer status", zap.String("Name", gameServer.Name), zap.Error(err))
+	}
+}
+
+func (m *Manager) setPlayersCondition(ctx context.Context, gameServer *crds.GameServer, condStatus, reason, message string) {
+	err := m.updateStatus(ctx, gameServer.Namespace, gameServer.Name, func(status *crds.GameServerStatus) {
+		setCondition(status, ConditionPlayersSynced, condStatus, reason, message)
+	})
+	if err != nil {
+		m.logger.Error("Failed to update GameServer status", zap.String("Name", gameServer.Name), zap.Error(err))
+	}
+}
+
+// listDrift describes how the live players of a list differ from the wanted
+// ones.
+func listDrift(list games.PlayerList, want, live []string) []string {
+	var drift []string
+
+	for _, name := range want {
+		if !containsPlayer(live, name) {
+			drift = append(drift, fmt.Sprintf("%s: %s was missing", list, name))
+		}
+	}
+
+	for _, name := range live {
+		if !containsPlayer(want, name) {
+			drift = append(drift, fmt.Sprintf("%s: %s was not in the spec", list, name))
+		}
+	}
+
+	return drift
+}
+
+// containsPlayer reports whether name is in names. Player names are case
+// insensitive.
+func containsPlayer(names []string, name string) bool {
+	for _, candidate := range names {
+		if strings.EqualFold(candidate, name) {
+			return true
+		}
+	}
+
+	return false
+}
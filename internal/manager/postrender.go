@@ -0,0 +1,73 @@
+package manager
+
+import (
+	"bytes"
+	"fmt"
+
+	"helm.sh/helm/v3/pkg/postrender"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+const (
+	postRenderManifests     = "/manifests.yaml"
+	postRenderKustomization = "/kustomization.yaml"
+)
+
+// patchPostRenderer applies spec.postRender.patches to the manifests Helm
+// renders, by running them through an in-memory kustomization.
+type patchPostRenderer struct {
+	patches []crds.Patch
+}
+
+// newPostRenderer returns the post-renderer of a GameServer, or nil when it
+// has no patches.
+func newPostRenderer(gameServer *crds.GameServer) postrender.PostRenderer {
+	if gameServer.Spec.PostRender == nil || len(gameServer.Spec.PostRender.Patches) == 0 {
+		return nil
+	}
+
+	return &patchPostRenderer{patches: gameServer.Spec.PostRender.Patches}
+}
+
+// Run implements postrender.PostRenderer.
+func (r *patchPostRenderer) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	if len(bytes.TrimSpace(renderedManifests.Bytes())) == 0 {
+		return renderedManifests, nil
+	}
+
+	kustomization, err := yaml.Marshal(map[string]any{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+		"resources":  []string{postRenderManifests[1:]},
+		"patches":    r.patches,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fs := filesys.MakeFsInMemory()
+
+	if err := fs.WriteFile(postRenderManifests, renderedManifests.Bytes()); err != nil {
+		return nil, err
+	}
+
+	if err := fs.WriteFile(postRenderKustomization, kustomization); err != nil {
+		return nil, err
+	}
+
+	resources, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(fs, "/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply postRender patches: %w", err)
+	}
+
+	patched, err := resources.AsYaml()
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewBuffer(patched), nil
+}
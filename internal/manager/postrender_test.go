@@ -0,0 +1,126 @@
+package manager
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+const renderedDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: survival
+spec:
+  template:
+    spec:
+      containers:
+      - name: server
+        image: itzg/minecraft-server
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: survival
+spec:
+  ports:
+  - port: 25565
+`
+
+func TestPostRenderPatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		patches []crds.Patch
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "strategic merge",
+			patches: []crds.Patch{{Patch: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: survival
+spec:
+  template:
+    spec:
+      nodeSelector:
+        pool: games
+`}},
+			want: []string{"pool: games", "image: itzg/minecraft-server"},
+		},
+		{
+			name: "json6902",
+			patches: []crds.Patch{{
+				Patch:  "- op: replace\n  path: /spec/ports/0/port\n  value: 25566\n",
+				Target: &crds.PatchTarget{Kind: "Service", Name: "survival"},
+			}},
+			want: []string{"port: 25566", "kind: Deployment"},
+		},
+		{
+			name: "applied in order",
+			patches: []crds.Patch{
+				{
+					Patch:  "- op: replace\n  path: /spec/ports/0/port\n  value: 25566\n",
+					Target: &crds.PatchTarget{Kind: "Service"},
+				},
+				{
+					Patch:  "- op: replace\n  path: /spec/ports/0/port\n  value: 25567\n",
+					Target: &crds.PatchTarget{Kind: "Service"},
+				},
+			},
+			want: []string{"port: 25567"},
+		},
+		{
+			name: "missing target",
+			patches: []crds.Patch{{
+				Patch:  "- op: remove\n  path: /spec/ports/3\n",
+				Target: &crds.PatchTarget{Kind: "Service"},
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		gameServer := &crds.GameServer{Spec: crds.GameServerSpec{PostRender: &crds.PostRender{Patches: test.patches}}}
+
+		patched, err := newPostRenderer(gameServer).Run(bytes.NewBufferString(renderedDeployment))
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: Run succeeded, want an error", test.name)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: Run: %v", test.name, err)
+
+			continue
+		}
+
+		for _, want := range test.want {
+			if !strings.Contains(patched.String(), want) {
+				t.Errorf("%s: patched manifests lack %q:\n%s", test.name, want, patched)
+			}
+		}
+	}
+}
+
+func TestPostRendererWithoutPatches(t *testing.T) {
+	for name, postRender := range map[string]*crds.PostRender{"unset": nil, "empty": {}} {
+		gameServer := &crds.GameServer{Spec: crds.GameServerSpec{PostRender: postRender}}
+
+		if renderer := newPostRenderer(gameServer); renderer != nil {
+			t.Errorf("%s: newPostRenderer = %v, want nil", name, renderer)
+		}
+	}
+
+	renderer := &patchPostRenderer{patches: []crds.Patch{{Patch: "kind: Service\nmetadata:\n  name: survival\n"}}}
+
+	empty := bytes.NewBufferString("\n")
+
+	if patched, err := renderer.Run(empty); err != nil || patched != empty {
+		t.Errorf("Run on empty manifests = %v, %v, want them untouched", patched, err)
+	}
+}
@@ -0,0 +1,98 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+	"github.com/Sackbuoy/gameserver-operator/internal/games"
+)
+
+// ConditionGameReady is True while the game answers its query protocol, so
+// it accepts players rather than merely running.
+const ConditionGameReady = "GameReady"
+
+// errNoGamePort is returned when no Service of a release exposes the port the
+// game is reached on.
+var errNoGamePort = errors.New("no Service of the release exposes the game port")
+
+// probes reports whether there is a driver to query the game of a GameServer
+// with.
+func probes(gameServer *crds.GameServer) bool {
+	return games.Lookup(gameServer.Spec.GameType) != nil
+}
+
+// probeGame queries the game of a GameServer through the Services of its
+// release, with the driver of its game type.
+func probeGame(ctx context.Context, gameServer *crds.GameServer, networking *crds.NetworkingStatus) (*crds.GameStatus, error) {
+	driver := games.Lookup(gameServer.Spec.GameType)
+
+	address, err := probeAddress(gameServer.Namespace, networking, driver.QueryPort())
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.Probe(ctx, address)
+}
+
+// probeAddress returns the in-cluster address of the Service port numbered
+// or named like port, or else of the first port speaking its protocol.
+func probeAddress(namespace string, networking *crds.NetworkingStatus, port games.Port) (string, error) {
+	return serviceAddress(namespace, networking, port, true)
+}
+
+// serviceAddress returns the in-cluster address of the Service port numbered
+// or named like port. With anyPort, the first port speaking its protocol
+// stands in for it.
+func serviceAddress(namespace string, networking *crds.NetworkingStatus, port games.Port, anyPort bool) (string, error) {
+	if networking == nil {
+		return "", errNoGamePort
+	}
+
+	var fallback string
+
+	for _, service := range networking.Services {
+		for _, servicePort := range service.Ports {
+			// Services default to TCP
+			if servicePort.Protocol != port.Protocol && (servicePort.Protocol != "" || port.Protocol != "TCP") {
+				continue
+			}
+
+			address := net.JoinHostPort(fmt.Sprintf("%s.%s.svc", service.Name, namespace), strconv.Itoa(int(servicePort.Port)))
+
+			if (port.Number != 0 && servicePort.Port == port.Number) ||
+				(port.Name != "" && strings.Contains(servicePort.Name, port.Name)) {
+				return address, nil
+			}
+
+			if fallback == "" && anyPort {
+				fallback = address
+			}
+		}
+	}
+
+	if fallback == "" {
+		return "", errNoGamePort
+	}
+
+	return fallback, nil
+}
+
+// recordProbe stores the outcome of a game query.
+func recordProbe(status *crds.GameServerStatus, game *crds.GameStatus, err error) {
+	status.Game = game
+
+	switch {
+	case err != nil:
+		setCondition(status, ConditionGameReady, ConditionFalse, "QueryFailed", err.Error())
+	case game == nil:
+		setCondition(status, ConditionGameReady, ConditionTrue, "PortOpen", "The game port accepts connections")
+	default:
+		setCondition(status, ConditionGameReady, ConditionTrue, "Responding",
+			fmt.Sprintf("%d/%d players online", game.OnlinePlayers, game.MaxPlayers))
+	}
+}
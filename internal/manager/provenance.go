@@ -0,0 +1,170 @@
+package manager
+
+import (
+	"context"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+const (
+	// provenanceValuesKey holds the deployed values, every leaf commented
+	// with the layer it came from
+	provenanceValuesKey = "values.yaml"
+	provenanceHashKey   = "hash"
+)
+
+// valuesConfigMapName is the ConfigMap the deployed values of a GameServer are
+// published in.
+func valuesConfigMapName(gameServer *crds.GameServer) string {
+	return gameServer.Name + "-values"
+}
+
+// publishValues writes the provenance annotated values of a GameServer to its
+// values ConfigMap, and returns the ConfigMap's name.
+func (m *Manager) publishValues(ctx context.Context, gameServer *crds.GameServer, resolved *resolvedValues) (string, error) {
+	annotated, err := resolved.annotatedYAML()
+	if err != nil {
+		return "", err
+	}
+
+	name := valuesConfigMapName(gameServer)
+
+	err = m.applyConfigMap(ctx, gameServer, name, map[string]string{
+		provenanceValuesKey: annotated,
+		provenanceHashKey:   resolved.hash,
+	}, true)
+	if err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// redactedValue stands in for the leaves of sensitive layers.
+const redactedValue = "(redacted)"
+
+// provenance returns the values Helm renders with, chart defaults included,
+// and the source of each of their leaves keyed by dotted path. Leaves set by
+// sensitive layers are redacted.
+func (r *resolvedValues) provenance() (map[string]any, map[string]string) {
+	merged := make(map[string]any)
+	for _, layer := range r.layers {
+		mergeValues(merged, layer.values)
+	}
+
+	sources := make(map[string]string)
+
+	var redacted [][]string
+
+	walkLeaves(merged, nil, func(path []string) {
+		// the source of a leaf is the last layer that set it
+		for i := len(r.layers) - 1; i >= 0; i-- {
+			if value, found := lookupValue(r.layers[i].values, path); found && isLeaf(value) {
+				sources[strings.Join(path, ".")] = r.layers[i].source
+
+				if r.layers[i].sensitive {
+					redacted = append(redacted, path)
+				}
+
+				return
+			}
+		}
+	})
+
+	// merged copied every table out of the layers, so this leaves them be
+	for _, path := range redacted {
+		if table, ok := lookupValue(merged, path[:len(path)-1]); ok {
+			table.(map[string]any)[path[len(path)-1]] = redactedValue
+		}
+	}
+
+	return merged, sources
+}
+
+// annotatedYAML renders the merged values with every leaf commented with
+// the layer it came from.
+func (r *resolvedValues) annotatedYAML() (string, error) {
+	merged, sources := r.provenance()
+
+	node, err := yaml.FromMap(merged)
+	if err != nil {
+		return "", err
+	}
+
+	annotateNode(node.YNode(), nil, sources)
+
+	return node.String()
+}
+
+func annotateNode(node *yaml.Node, path []string, sources map[string]string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		keyPath := append(append([]string{}, path...), key.Value)
+
+		if value.Kind == yaml.MappingNode && len(value.Content) > 0 {
+			annotateNode(value, keyPath, sources)
+
+			continue
+		}
+
+		source, ok := sources[strings.Join(keyPath, ".")]
+		if !ok {
+			continue
+		}
+
+		// block sequences start on the next line, so comment their key
+		if value.Kind == yaml.ScalarNode || value.Style&yaml.FlowStyle != 0 {
+			value.LineComment = "from " + source
+		} else {
+			key.LineComment = "from " + source
+		}
+	}
+}
+
+// walkLeaves calls fn with the path of every leaf of values. Lists and empty
+// tables are leaves.
+func walkLeaves(values map[string]any, path []string, fn func(path []string)) {
+	for key, value := range values {
+		keyPath := append(append([]string{}, path...), key)
+
+		if table, ok := value.(map[string]any); ok && len(table) > 0 {
+			walkLeaves(table, keyPath, fn)
+
+			continue
+		}
+
+		fn(keyPath)
+	}
+}
+
+// lookupValue reads the value at a path of keys.
+func lookupValue(values map[string]any, path []string) (any, bool) {
+	var current any = values
+
+	for _, key := range path {
+		table, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		current, ok = table[key]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+func isLeaf(value any) bool {
+	table, ok := value.(map[string]any)
+
+	return !ok || len(table) == 0
+}
@@ -0,0 +1,113 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// valuesInvalidError lists the schema violations of a GameServer's values,
+// each prefixed with the JSON path it was found at.
+type valuesInvalidError struct {
+	violations []string
+}
+
+func (e *valuesInvalidError) Error() string {
+	return "values don't match schema: " + strings.Join(e.violations, "; ")
+}
+
+// validateValues checks the values a GameServer is deployed with against the
+// values.schema.json of its chart and subcharts, and against the schema of its
+// GameDefinition. Chart defaults are coalesced in first, as Helm does.
+func validateValues(g *game, values map[string]any) error {
+	coalesced, err := chartutil.CoalesceValues(g.chart, values)
+	if err != nil {
+		return err
+	}
+
+	violations, err := chartSchemaViolations(g.chart, coalesced, "$")
+	if err != nil {
+		return err
+	}
+
+	if g.definition != nil && g.definition.Spec.ValuesSchema != "" {
+		found, err := schemaViolations([]byte(g.definition.Spec.ValuesSchema), coalesced, "$")
+		if err != nil {
+			return fmt.Errorf("invalid valuesSchema in GameDefinition %s: %w", g.definition.Name, err)
+		}
+
+		violations = append(violations, found...)
+	}
+
+	if len(violations) > 0 {
+		return &valuesInvalidError{violations: violations}
+	}
+
+	return nil
+}
+
+// chartSchemaViolations validates values against a chart's schema and,
+// under their own keys, against the schemas of its dependencies.
+func chartSchemaViolations(chrt *chart.Chart, values map[string]any, path string) ([]string, error) {
+	var violations []string
+
+	if chrt.Schema != nil {
+		found, err := schemaViolations(chrt.Schema, values, path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid values.schema.json in chart %s: %w", chrt.Name(), err)
+		}
+
+		violations = append(violations, found...)
+	}
+
+	for _, dependency := range chrt.Dependencies() {
+		dependencyValues, _ := values[dependency.Name()].(map[string]any)
+
+		found, err := chartSchemaViolations(dependency, dependencyValues, path+"."+dependency.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		violations = append(violations, found...)
+	}
+
+	return violations, nil
+}
+
+// schemaViolations validates values against a JSON schema and returns every
+// violation as "<JSON path>: <description>".
+func schemaViolations(schemaJSON []byte, values map[string]any, path string) ([]string, error) {
+	if values == nil {
+		values = map[string]any{}
+	}
+
+	valuesJSON, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schemaJSON), gojsonschema.NewBytesLoader(valuesJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	violations := make([]string, 0, len(result.Errors()))
+
+	for _, resultError := range result.Errors() {
+		fieldPath := path
+		if field := resultError.Field(); field != gojsonschema.STRING_ROOT_SCHEMA_PROPERTY {
+			fieldPath += "." + field
+		}
+
+		violations = append(violations, fmt.Sprintf("%s: %s", fieldPath, resultError.Description()))
+	}
+
+	sort.Strings(violations)
+
+	return violations, nil
+}
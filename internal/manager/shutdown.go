@@ -0,0 +1,302 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	"sigs.k8s.io/yaml"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+	"github.com/Sackbuoy/gameserver-operator/internal/games"
+	"github.com/Sackbuoy/gameserver-operator/internal/query"
+)
+
+// defaultShutdownCountdown and defaultShutdownTimeout match the defaults of
+// spec.shutdown.
+const (
+	defaultShutdownCountdown = 60 * time.Second
+	defaultShutdownTimeout   = 60 * time.Second
+)
+
+// stoppedPollInterval is how often a stopping game is checked on.
+const stoppedPollInterval = 2 * time.Second
+
+// countdownMarks are the remaining times players are warned at.
+var countdownMarks = []time.Duration{
+	10 * time.Minute, 5 * time.Minute, 2 * time.Minute, time.Minute,
+	30 * time.Second, 10 * time.Second, 5 * time.Second,
+	3 * time.Second, 2 * time.Second, time.Second,
+}
+
+func shutdownCountdown(gameServer *crds.GameServer) time.Duration {
+	if gameServer.Spec.Shutdown == nil {
+		return defaultShutdownCountdown
+	}
+
+	return time.Duration(gameServer.Spec.Shutdown.Countdown) * time.Second
+}
+
+func shutdownTimeout(gameServer *crds.GameServer) time.Duration {
+	if gameServer.Spec.Shutdown == nil || gameServer.Spec.Shutdown.Timeout <= 0 {
+		return defaultShutdownTimeout
+	}
+
+	return time.Duration(gameServer.Spec.Shutdown.Timeout) * time.Second
+}
+
+// stopGame warns the players of a GameServer with a countdown, then has the
+// game save and stop when its driver can, before an action disrupts it. The game is handed over
+// as it is when that fails, so a broken console never holds up a release.
+func (m *Manager) stopGame(ctx context.Context, gameServer *crds.GameServer, reason string) {
+	m.shutDown(ctx, gameServer, reason, nil)
+}
+
+// beforeStopFunc runs on the console of a game between the countdown and
+// stopping it. The game is left running when it fails.
+type beforeStopFunc func(ctx context.Context, driver games.Driver, console games.Console) error
+
+// shutDown is stopGame, running beforeStop, if set, once the players have
+// been warned. beforeStop doesn't run when the game isn't stopped.
+func (m *Manager) shutDown(ctx context.Context, gameServer *crds.GameServer, reason string, beforeStop beforeStopFunc) {
+	if gameServer.Spec.Shutdown != nil && gameServer.Spec.Shutdown.Disabled {
+		return
+	}
+
+	// nothing runs that could be stopped
+	if deployment := gameServer.Status.Deployment; deployment == nil || deployment.ReadyReplicas == 0 {
+		return
+	}
+
+	driver := games.Lookup(gameServer.Spec.GameType)
+	if driver == nil {
+		return
+	}
+
+	console, err := m.console(ctx, gameServer)
+	if errors.Is(err, errNoConsole) {
+		return
+	}
+
+	if err != nil {
+		m.logger.Error("Failed to reach console for shutdown", zap.String("Name", gameServer.Name), zap.Error(err))
+		m.recordEvent(ctx, gameServer, EventTypeWarning, "ShutdownSkipped",
+			fmt.Sprintf("Game not stopped before %s: %v", reason, err))
+
+		return
+	}
+	defer console.Close()
+
+	countdown := shutdownCountdown(gameServer)
+
+	if players := m.onlinePlayers(ctx, gameServer, driver); players == 0 {
+		countdown = 0
+	}
+
+	if err := m.countdown(ctx, driver, console, countdown, reason); err != nil {
+		m.logger.Error("Failed to warn players", zap.String("Name", gameServer.Name), zap.Error(err))
+	}
+
+	if beforeStop != nil {
+		if err := beforeStop(ctx, driver, console); err != nil {
+			return
+		}
+	}
+
+	// games the driver can't stop go down with their pods, there is nothing
+	// to wait for
+	stopper, ok := driver.(games.Stopper)
+	if !ok {
+		m.logger.Info("Warned players, the game stops with its pods", zap.String("Name", gameServer.Name), zap.String("Reason", reason))
+
+		return
+	}
+
+	if err := stopper.Stop(ctx, console); err != nil {
+		m.logger.Error("Failed to stop game", zap.String("Name", gameServer.Name), zap.Error(err))
+		m.recordEvent(ctx, gameServer, EventTypeWarning, "ShutdownFailed",
+			fmt.Sprintf("Game not stopped before %s: %v", reason, err))
+
+		return
+	}
+
+	if !m.waitStopped(ctx, gameServer, driver, shutdownTimeout(gameServer)) {
+		m.recordEvent(ctx, gameServer, EventTypeWarning, "ShutdownTimedOut",
+			fmt.Sprintf("Game still answering %s after being stopped, proceeding with %s", shutdownTimeout(gameServer), reason))
+
+		return
+	}
+
+	m.logger.Info("Stopped game", zap.String("Name", gameServer.Name), zap.String("Reason", reason))
+	m.recordEvent(ctx, gameServer, EventTypeNormal, "GameStopped", fmt.Sprintf("Game saved and stopped before %s", reason))
+}
+
+// onlinePlayers asks the game how many players are online, falling back to
+// the last probe. It is -1 when unknown.
+func (m *Manager) onlinePlayers(ctx context.Context, gameServer *crds.GameServer, driver games.Driver) int32 {
+	probeCtx, cancel := context.WithTimeout(ctx, query.DefaultTimeout)
+	defer cancel()
+
+	address, err := probeAddress(gameServer.Namespace, gameServer.Status.Networking, driver.QueryPort())
+	if err == nil {
+		if game, err := driver.Probe(probeCtx, address); err == nil && game != nil {
+			return game.OnlinePlayers
+		}
+	}
+
+	if gameServer.Status.Game != nil {
+		return gameServer.Status.Game.OnlinePlayers
+	}
+
+	return -1
+}
+
+// countdown broadcasts the time left before reason at every countdown mark.
+func (m *Manager) countdown(ctx context.Context, driver games.Driver, console games.Console, countdown time.Duration, reason string) error {
+	if countdown <= 0 {
+		return nil
+	}
+
+	deadline := time.Now().Add(countdown)
+
+	if err := driver.Broadcast(ctx, console, fmt.Sprintf("Server %s in %s", reason, spellDuration(countdown))); err != nil {
+		return err
+	}
+
+	for _, mark := range countdownMarks {
+		if mark >= countdown {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Until(deadline.Add(-mark))):
+		}
+
+		if err := driver.Broadcast(ctx, console, fmt.Sprintf("Server %s in %s", reason, spellDuration(mark))); err != nil {
+			return err
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(deadline)):
+	}
+
+	return nil
+}
+
+// spellDuration spells a countdown out for players, in whole minutes or
+// seconds.
+func spellDuration(d time.Duration) string {
+	value, unit := int(d.Round(time.Second)/time.Second), "second"
+	if value >= 120 && value%60 == 0 {
+		value, unit = value/60, "minute"
+	}
+
+	if value == 1 {
+		return "1 " + unit
+	}
+
+	return fmt.Sprintf("%d %ss", value, unit)
+}
+
+// waitStopped waits until the game stops answering its query port, and
+// reports whether it did within timeout.
+func (m *Manager) waitStopped(ctx context.Context, gameServer *crds.GameServer, driver games.Driver, timeout time.Duration) bool {
+	address, err := probeAddress(gameServer.Namespace, gameServer.Status.Networking, driver.QueryPort())
+	if err != nil {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(stoppedPollInterval)
+	defer ticker.Stop()
+
+	for {
+		probeCtx, cancelProbe := context.WithTimeout(ctx, stoppedPollInterval)
+		_, err := driver.Probe(probeCtx, address)
+		cancelProbe()
+
+		if err != nil && ctx.Err() == nil {
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// restartsWorkloads reports whether upgrading live to the resolved values
+// changes the pod template of a Deployment or StatefulSet, or removes one,
+// so that the game restarts.
+func restartsWorkloads(ctx context.Context, actionConfig *action.Configuration, gameServer *crds.GameServer, g *game, resolved *resolvedValues, live *release.Release) (bool, error) {
+	upgrader := action.NewUpgrade(actionConfig)
+	upgrader.Namespace = gameServer.Namespace
+	upgrader.DryRunOption = "client"
+	upgrader.HideSecret = true
+	upgrader.PostRenderer = newPostRenderer(gameServer)
+
+	rendered, err := upgrader.RunWithContext(ctx, live.Name, g.chart, resolved.values)
+	if err != nil {
+		return false, err
+	}
+
+	before, err := splitManifest(live.Manifest)
+	if err != nil {
+		return false, err
+	}
+
+	after, err := splitManifest(rendered.Manifest)
+	if err != nil {
+		return false, err
+	}
+
+	for key, document := range before {
+		if !strings.HasPrefix(key, "Deployment/") && !strings.HasPrefix(key, "StatefulSet/") {
+			continue
+		}
+
+		changed, err := podTemplateChanged(document, after[key])
+		if err != nil || changed {
+			return true, err
+		}
+	}
+
+	return false, nil
+}
+
+func podTemplateChanged(before, after string) (bool, error) {
+	if after == "" {
+		return true, nil
+	}
+
+	var beforeWorkload, afterWorkload struct {
+		Spec struct {
+			Template any `json:"template"`
+		} `json:"spec"`
+	}
+
+	if err := yaml.Unmarshal([]byte(before), &beforeWorkload); err != nil {
+		return false, err
+	}
+
+	if err := yaml.Unmarshal([]byte(after), &afterWorkload); err != nil {
+		return false, err
+	}
+
+	return !reflect.DeepEqual(beforeWorkload.Spec.Template, afterWorkload.Spec.Template), nil
+}
@@ -0,0 +1,291 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/release"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+const (
+	PhaseDeploying = "Deploying"
+	PhaseRunning   = "Running"
+	PhaseFailed    = "Failed"
+)
+
+const (
+	// ConditionMappingConflict is True when typed spec fields are shadowed by
+	// explicit chart values.
+	ConditionMappingConflict = "MappingConflict"
+	// ConditionReleased is True once the current spec is deployed and its
+	// workloads are ready, and Unknown while a release operation waits on them.
+	ConditionReleased = "Released"
+	// ConditionChartTestsPassed reports the outcome of the chart's Helm tests
+	// against the deployed release, when spec.helmChart.runTests is set.
+	ConditionChartTestsPassed = "ChartTestsPassed"
+	// ConditionValuesInvalid is True when the merged values violate the chart's
+	// or the game type's schema, listing each violation by JSON path.
+	ConditionValuesInvalid = "ValuesInvalid"
+)
+
+// progressInterval is how often status is refreshed while a release
+// operation waits for its workloads.
+const progressInterval = 10 * time.Second
+
+const (
+	ConditionTrue    = "True"
+	ConditionFalse   = "False"
+	ConditionUnknown = "Unknown"
+)
+
+// getGameServer reads the current copy of a GameServer from the cluster, with
+// its spec as stored for applyTemplate.
+func (m *Manager) getGameServer(ctx context.Context, namespace, name string) (*crds.GameServer, map[string]any, error) {
+	obj, err := m.k8sClient.Resource(crds.GameServerResource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var gameServer crds.GameServer
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &gameServer); err != nil {
+		return nil, nil, fmt.Errorf("failed to convert %s/%s: %w", namespace, name, err)
+	}
+
+	return &gameServer, specObject(obj.Object), nil
+}
+
+// updateStatus applies mutate to the latest status of a GameServer and writes
+// it back through the status subresource, retrying on conflicts.
+func (m *Manager) updateStatus(ctx context.Context, namespace, name string, mutate func(*crds.GameServerStatus)) error {
+	client := m.k8sClient.Resource(crds.GameServerResource).Namespace(namespace)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := client.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		var gameServer crds.GameServer
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &gameServer); err != nil {
+			return err
+		}
+
+		original, err := json.Marshal(gameServer.Status)
+		if err != nil {
+			return err
+		}
+
+		mutate(&gameServer.Status)
+
+		// every status write shows up as a MODIFIED event, so skip writes
+		// that change nothing
+		if mutated, err := json.Marshal(gameServer.Status); err == nil && bytes.Equal(original, mutated) {
+			return nil
+		}
+
+		now := metav1.Now()
+		gameServer.Status.LastUpdated = &now
+
+		status, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&gameServer.Status)
+		if err != nil {
+			return err
+		}
+
+		obj.Object["status"] = status
+
+		_, err = client.UpdateStatus(ctx, obj, metav1.UpdateOptions{})
+
+		return err
+	})
+}
+
+// setCondition adds or updates a condition, keeping its transition time when
+// the status did not change.
+func setCondition(status *crds.GameServerStatus, condType, condStatus, reason, message string) {
+	now := metav1.Now()
+
+	for i := range status.Conditions {
+		condition := &status.Conditions[i]
+		if condition.Type != condType {
+			continue
+		}
+
+		if condition.Status != condStatus {
+			condition.LastTransitionTime = &now
+		}
+
+		condition.Status = condStatus
+		condition.Reason = reason
+		condition.Message = message
+
+		return
+	}
+
+	status.Conditions = append(status.Conditions, crds.GameServerCondition{
+		Type:               condType,
+		Status:             condStatus,
+		LastTransitionTime: &now,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// removeCondition drops a condition that no longer applies.
+func removeCondition(status *crds.GameServerStatus, condType string) {
+	conditions := status.Conditions[:0]
+
+	for _, condition := range status.Conditions {
+		if condition.Type != condType {
+			conditions = append(conditions, condition)
+		}
+	}
+
+	status.Conditions = conditions
+}
+
+// recordRelease stores the outcome of a successful install or upgrade.
+func (m *Manager) recordRelease(ctx context.Context, gameServer *crds.GameServer, rel *release.Release, resolved *resolvedValues) error {
+	valuesConfigMap, err := m.publishValues(ctx, gameServer, resolved)
+	if err != nil {
+		m.logger.Error("Failed to publish values", zap.String("Name", gameServer.Name), zap.Error(err))
+	}
+
+	return m.updateStatus(ctx, gameServer.Namespace, gameServer.Name, func(status *crds.GameServerStatus) {
+		lastDeployed := metav1.NewTime(rel.Info.LastDeployed.Time)
+
+		if len(resolved.conflicts) > 0 {
+			setCondition(status, ConditionMappingConflict, ConditionTrue, "ValuesOverrideWins", strings.Join(resolved.conflicts, "; "))
+		} else {
+			setCondition(status, ConditionMappingConflict, ConditionFalse, "NoConflicts", "")
+		}
+
+		status.Phase = PhaseRunning
+		status.Message = rel.Info.Description
+		setCondition(status, ConditionValuesInvalid, ConditionFalse, "SchemaValid", "")
+		removeCondition(status, ConditionDryRun)
+		setCondition(status, ConditionReleased, ConditionTrue, "Deployed", rel.Info.Description)
+		status.ObservedGeneration = gameServer.Generation
+		status.Template = gameServer.Status.Template
+
+		// the first release starts out with a fresh RCON password
+		if status.RCON == nil && gameServer.Annotations[rotateRCONAnnotation] != "" {
+			status.RCON = &crds.RCONStatus{RotationRequest: gameServer.Annotations[rotateRCONAnnotation]}
+		}
+
+		status.FailedRelease = nil
+		status.HelmRelease = &crds.HelmReleaseStatus{
+			Name:            rel.Name,
+			Version:         rel.Version,
+			LastDeployed:    &lastDeployed,
+			ValuesHash:      resolved.hash,
+			ValuesConfigMap: valuesConfigMap,
+			StorageDriver:   m.storageDriver(gameServer),
+		}
+	})
+}
+
+// recordFailure marks a GameServer as failed with the error that caused it.
+func (m *Manager) recordFailure(ctx context.Context, gameServer *crds.GameServer, reason string, cause error) {
+	err := m.updateStatus(ctx, gameServer.Namespace, gameServer.Name, func(status *crds.GameServerStatus) {
+		status.Phase = PhaseFailed
+		status.Message = cause.Error()
+		setCondition(status, ConditionReleased, ConditionFalse, reason, cause.Error())
+	})
+	if err != nil {
+		m.logger.Error("Failed to update GameServer status", zap.String("Name", gameServer.Name), zap.Error(err))
+	}
+}
+
+// recordFailedRelease marks a GameServer as failed by an install or upgrade,
+// remembering the generation and values that failed so they aren't retried.
+func (m *Manager) recordFailedRelease(ctx context.Context, gameServer *crds.GameServer, reason, valuesHash string, cause error) {
+	err := m.updateStatus(ctx, gameServer.Namespace, gameServer.Name, func(status *crds.GameServerStatus) {
+		now := metav1.Now()
+
+		status.Phase = PhaseFailed
+		status.Message = cause.Error()
+		setCondition(status, ConditionReleased, ConditionFalse, reason, cause.Error())
+		status.FailedRelease = &crds.FailedReleaseStatus{
+			Generation: gameServer.Generation,
+			ValuesHash: valuesHash,
+			Reason:     reason,
+			FailedAt:   &now,
+		}
+	})
+	if err != nil {
+		m.logger.Error("Failed to update GameServer status", zap.String("Name", gameServer.Name), zap.Error(err))
+	}
+}
+
+// recordInvalidValues marks a GameServer as failed because its values
+// violate a schema.
+func (m *Manager) recordInvalidValues(ctx context.Context, gameServer *crds.GameServer, invalid *valuesInvalidError) {
+	message := strings.Join(invalid.violations, "; ")
+
+	err := m.updateStatus(ctx, gameServer.Namespace, gameServer.Name, func(status *crds.GameServerStatus) {
+		status.Phase = PhaseFailed
+		status.Message = invalid.Error()
+		setCondition(status, ConditionValuesInvalid, ConditionTrue, "SchemaViolation", message)
+		setCondition(status, ConditionReleased, ConditionFalse, "ValuesInvalid", invalid.Error())
+	})
+	if err != nil {
+		m.logger.Error("Failed to update GameServer status", zap.String("Name", gameServer.Name), zap.Error(err))
+	}
+}
+
+// trackProgress reports a release operation waiting for its workloads in
+// status, until the returned function is called.
+func (m *Manager) trackProgress(ctx context.Context, gameServer *crds.GameServer, operation string, timeout time.Duration) func() {
+	started := time.Now()
+
+	report := func() {
+		elapsed := time.Since(started).Round(time.Second)
+		message := fmt.Sprintf("%s: waiting for workloads to become ready (%s of %s)", operation, elapsed, timeout)
+
+		err := m.updateStatus(ctx, gameServer.Namespace, gameServer.Name, func(status *crds.GameServerStatus) {
+			status.Phase = PhaseDeploying
+			status.Message = message
+			setCondition(status, ConditionReleased, ConditionUnknown, operation, message)
+		})
+		if err != nil {
+			m.logger.Error("Failed to update GameServer status", zap.String("Name", gameServer.Name), zap.Error(err))
+		}
+	}
+
+	report()
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				report()
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		// don't let a late progress report overwrite the outcome
+		<-stopped
+	}
+}
@@ -0,0 +1,44 @@
+package manager
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+func TestTrackProgress(t *testing.T) {
+	gameServer := &crds.GameServer{ObjectMeta: metav1.ObjectMeta{Namespace: "games", Name: "survival"}}
+
+	m, cluster := newFakeManager(t, gameServerObject(t, gameServer))
+
+	stop := m.trackProgress(context.Background(), gameServer, "Install", 90*time.Second)
+	stop()
+
+	status := cluster.gameServer(t, "games", "survival").Status
+	if status.Phase != PhaseDeploying {
+		t.Errorf("phase = %q, want %q", status.Phase, PhaseDeploying)
+	}
+
+	if !strings.Contains(status.Message, "of 1m30s") {
+		t.Errorf("message = %q, want it to mention the timeout", status.Message)
+	}
+
+	condition := findCondition(status, ConditionReleased)
+	if condition == nil || condition.Status != ConditionUnknown || condition.Reason != "Install" {
+		t.Errorf("Released condition = %+v, want Unknown with reason Install", condition)
+	}
+
+	// stopping waits for the reporter, so nothing is written after it
+	writes := len(cluster.writes())
+
+	time.Sleep(10 * time.Millisecond)
+
+	if after := len(cluster.writes()); after != writes {
+		t.Errorf("%d status writes after stopping", after-writes)
+	}
+}
@@ -0,0 +1,170 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+// applyTemplate merges the template a GameServer references underneath its
+// own spec, and records what it merged in gameServer.Status.Template. local
+// is the spec as stored, see specObject.
+//
+// Unless the reference opts into autoUpdate, a GameServer keeps the template
+// spec it was last deployed with until its own generation changes.
+func (m *Manager) applyTemplate(ctx context.Context, gameServer *crds.GameServer, local map[string]any) error {
+	ref := gameServer.Spec.TemplateRef
+	if ref == nil {
+		gameServer.Status.Template = nil
+
+		return nil
+	}
+
+	kind := ref.Kind
+	if kind == "" {
+		kind = crds.GameServerTemplateKind
+	}
+
+	template := gameServer.Status.Template
+
+	pinned := !ref.AutoUpdate &&
+		gameServer.Status.ObservedGeneration == gameServer.Generation &&
+		template != nil && template.Spec != nil &&
+		template.Kind == kind && template.Name == ref.Name
+
+	if !pinned {
+		var err error
+
+		template, err = m.getTemplate(ctx, kind, gameServer.Namespace, ref.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	spec, err := mergeSpecs(*template.Spec, local)
+	if err != nil {
+		return fmt.Errorf("failed to merge %s %s: %w", kind, ref.Name, err)
+	}
+
+	gameServer.Spec = spec
+	gameServer.Status.Template = template
+
+	return nil
+}
+
+// getTemplate reads a template into the form it is recorded in status.
+func (m *Manager) getTemplate(ctx context.Context, kind, namespace, name string) (*crds.TemplateStatus, error) {
+	var client dynamic.ResourceInterface
+
+	switch kind {
+	case crds.GameServerTemplateKind:
+		client = m.k8sClient.Resource(crds.GameServerTemplateResource).Namespace(namespace)
+	case crds.ClusterGameServerTemplateKind:
+		client = m.k8sClient.Resource(crds.ClusterGameServerTemplateResource)
+	default:
+		return nil, fmt.Errorf("unsupported template kind %q", kind)
+	}
+
+	obj, err := client.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s %s: %w", kind, name, err)
+	}
+
+	var template crds.GameServerTemplate
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &template); err != nil {
+		return nil, fmt.Errorf("failed to convert %s %s: %w", kind, name, err)
+	}
+
+	// templates don't chain
+	template.Spec.TemplateRef = nil
+
+	return &crds.TemplateStatus{
+		Kind:       kind,
+		Name:       name,
+		Generation: template.Generation,
+		Spec:       &template.Spec,
+	}, nil
+}
+
+// specObject returns the spec of a GameServer object as stored. Unlike the
+// typed spec, it tells fields set to false, 0 or "" apart from unset ones.
+func specObject(obj map[string]any) map[string]any {
+	spec, found, err := unstructured.NestedMap(obj, "spec")
+	if err != nil || !found {
+		return map[string]any{}
+	}
+
+	return spec
+}
+
+// mergeSpecs deep merges a GameServer's own spec, as stored, over a template
+// spec. Every field the GameServer sets wins, zero values included. Lists are
+// replaced, except valuesFrom and postRender.patches which append the
+// GameServer's entries to the template's, and valuesOverride is merged as YAML.
+func mergeSpecs(template crds.GameServerSpec, local map[string]any) (crds.GameServerSpec, error) {
+	var localSpec crds.GameServerSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(local, &localSpec); err != nil {
+		return localSpec, err
+	}
+
+	templateMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&template)
+	if err != nil {
+		return localSpec, err
+	}
+
+	var spec crds.GameServerSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(mergeValues(templateMap, runtime.DeepCopyJSON(local)), &spec); err != nil {
+		return localSpec, err
+	}
+
+	spec.HelmChart.ValuesOverride, err = mergeValuesYAML(template.HelmChart.ValuesOverride, localSpec.HelmChart.ValuesOverride)
+	if err != nil {
+		return localSpec, err
+	}
+
+	spec.HelmChart.ValuesFrom = append(append([]crds.ValuesReference{}, template.HelmChart.ValuesFrom...), localSpec.HelmChart.ValuesFrom...)
+	if template.PostRender != nil && localSpec.PostRender != nil {
+		spec.PostRender.Patches = append(append([]crds.Patch{}, template.PostRender.Patches...), localSpec.PostRender.Patches...)
+	}
+
+	// release names identify a single GameServer
+	spec.HelmChart.ReleaseName = localSpec.HelmChart.ReleaseName
+	spec.TemplateRef = localSpec.TemplateRef
+
+	return spec, nil
+}
+
+// mergeValuesYAML deep merges two values documents, override taking precedence.
+func mergeValuesYAML(base, override string) (string, error) {
+	if base == "" {
+		return override, nil
+	}
+
+	if override == "" {
+		return base, nil
+	}
+
+	baseValues := make(map[string]any)
+	if err := yaml.Unmarshal([]byte(base), &baseValues); err != nil {
+		return "", fmt.Errorf("failed to parse template valuesOverride: %w", err)
+	}
+
+	overrideValues := make(map[string]any)
+	if err := yaml.Unmarshal([]byte(override), &overrideValues); err != nil {
+		return "", fmt.Errorf("failed to parse valuesOverride: %w", err)
+	}
+
+	merged, err := yaml.Marshal(mergeValues(baseValues, overrideValues))
+	if err != nil {
+		return "", err
+	}
+
+	return string(merged), nil
+}
@@ -0,0 +1,182 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+func TestMergeSpecsKeepsZeroOverrides(t *testing.T) {
+	template := crds.GameServerSpec{
+		GameType:      "minecraft-java",
+		AdoptExisting: true,
+		Shutdown:      &crds.ShutdownConfig{Countdown: 300, Timeout: 120},
+		Persistence:   &crds.PersistenceConfig{Enabled: true, Size: "10Gi"},
+		Players:       &crds.PlayersConfig{Allowlist: []string{"Alice"}},
+		HelmChart: crds.HelmChart{
+			RunTests:       true,
+			ValuesOverride: "motd: template\n",
+		},
+	}
+
+	spec, err := mergeSpecs(template, map[string]any{
+		"adoptExisting": false,
+		"shutdown":      map[string]any{"countdown": int64(0)},
+		"persistence":   map[string]any{"enabled": false},
+		"players":       map[string]any{"allowlist": []any{}},
+		"helmChart": map[string]any{
+			"runTests":       false,
+			"valuesOverride": "difficulty: hard\n",
+		},
+	})
+	if err != nil {
+		t.Fatalf("mergeSpecs: %v", err)
+	}
+
+	if spec.AdoptExisting {
+		t.Error("adoptExisting: false was dropped")
+	}
+
+	if spec.HelmChart.RunTests {
+		t.Error("helmChart.runTests: false was dropped")
+	}
+
+	if spec.Persistence == nil || spec.Persistence.Enabled || spec.Persistence.Size != "10Gi" {
+		t.Errorf("persistence = %+v, want disabled with the template's size", spec.Persistence)
+	}
+
+	if spec.Shutdown == nil || spec.Shutdown.Countdown != 0 || spec.Shutdown.Timeout != 120 {
+		t.Errorf("shutdown = %+v, want no countdown and the template's timeout", spec.Shutdown)
+	}
+
+	if spec.Players == nil || spec.Players.Allowlist == nil || len(spec.Players.Allowlist) != 0 {
+		t.Errorf("players = %+v, want an empty allowlist", spec.Players)
+	}
+
+	if spec.GameType != "minecraft-java" {
+		t.Errorf("gameType = %q, want the template's", spec.GameType)
+	}
+
+	if spec.HelmChart.ValuesOverride != "difficulty: hard\nmotd: template\n" {
+		t.Errorf("valuesOverride = %q, want both documents merged", spec.HelmChart.ValuesOverride)
+	}
+}
+
+func TestMergeSpecsUnsetFieldsKeepTemplate(t *testing.T) {
+	template := crds.GameServerSpec{
+		AdoptExisting: true,
+		Shutdown:      &crds.ShutdownConfig{Countdown: 300, Disabled: true},
+		HelmChart:     crds.HelmChart{RunTests: true, ReleaseName: "template"},
+	}
+
+	spec, err := mergeSpecs(template, map[string]any{
+		"gameType":  "rust",
+		"helmChart": map[string]any{"maxHistory": int64(3)},
+	})
+	if err != nil {
+		t.Fatalf("mergeSpecs: %v", err)
+	}
+
+	if !spec.AdoptExisting || !spec.HelmChart.RunTests || spec.Shutdown == nil || !spec.Shutdown.Disabled {
+		t.Errorf("spec = %+v, want the template's settings kept", spec)
+	}
+
+	if spec.GameType != "rust" || spec.HelmChart.MaxHistory != 3 {
+		t.Errorf("spec = %+v, want the GameServer's settings on top", spec)
+	}
+
+	if spec.HelmChart.ReleaseName != "" {
+		t.Errorf("releaseName = %q, templates can't name releases", spec.HelmChart.ReleaseName)
+	}
+}
+
+func TestMergeSpecsAppendsLists(t *testing.T) {
+	template := crds.GameServerSpec{
+		HelmChart: crds.HelmChart{
+			ValuesFrom: []crds.ValuesReference{{Kind: "ConfigMap", Name: "shared"}},
+		},
+		PostRender: &crds.PostRender{Patches: []crds.Patch{{Patch: "template"}}},
+		Networking: &crds.NetworkingConfig{Ports: []crds.PortConfig{{Port: 25565}, {Port: 25575}}},
+	}
+
+	spec, err := mergeSpecs(template, map[string]any{
+		"helmChart": map[string]any{
+			"valuesFrom": []any{map[string]any{"kind": "Secret", "name": "own"}},
+		},
+		"postRender": map[string]any{"patches": []any{map[string]any{"patch": "own"}}},
+		"networking": map[string]any{"ports": []any{map[string]any{"port": int64(30000)}}},
+	})
+	if err != nil {
+		t.Fatalf("mergeSpecs: %v", err)
+	}
+
+	if len(spec.HelmChart.ValuesFrom) != 2 || spec.HelmChart.ValuesFrom[0].Name != "shared" || spec.HelmChart.ValuesFrom[1].Name != "own" {
+		t.Errorf("valuesFrom = %+v, want the template's then the GameServer's", spec.HelmChart.ValuesFrom)
+	}
+
+	if spec.PostRender == nil || len(spec.PostRender.Patches) != 2 || spec.PostRender.Patches[1].Patch != "own" {
+		t.Errorf("postRender = %+v, want the template's then the GameServer's patches", spec.PostRender)
+	}
+
+	if spec.Networking == nil || len(spec.Networking.Ports) != 1 || spec.Networking.Ports[0].Port != 30000 {
+		t.Errorf("networking = %+v, want the GameServer's ports replacing the template's", spec.Networking)
+	}
+}
+
+func TestMergeValuesYAML(t *testing.T) {
+	tests := []struct {
+		base, override, want string
+	}{
+		{"", "motd: own\n", "motd: own\n"},
+		{"motd: template\n", "", "motd: template\n"},
+		{"server:\n  motd: template\n  pvp: true\n", "server:\n  pvp: false\n", "server:\n  motd: template\n  pvp: false\n"},
+	}
+
+	for _, test := range tests {
+		merged, err := mergeValuesYAML(test.base, test.override)
+		if err != nil || merged != test.want {
+			t.Errorf("mergeValuesYAML(%q, %q) = %q, %v, want %q", test.base, test.override, merged, err, test.want)
+		}
+	}
+
+	if _, err := mergeValuesYAML("motd: [", "pvp: false\n"); err == nil {
+		t.Error("mergeValuesYAML accepted a broken template document")
+	}
+}
+
+func TestApplyTemplateKeepsDeployedTemplate(t *testing.T) {
+	deployed := &crds.TemplateStatus{
+		Kind:       crds.GameServerTemplateKind,
+		Name:       "survival",
+		Generation: 1,
+		Spec:       &crds.GameServerSpec{GameType: "minecraft-java", DeletionPolicy: "Snapshot"},
+	}
+
+	gameServer := &crds.GameServer{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "games", Name: "survival", Generation: 3},
+		Spec:       crds.GameServerSpec{TemplateRef: &crds.TemplateReference{Name: "survival"}},
+		Status:     crds.GameServerStatus{ObservedGeneration: 3, Template: deployed},
+	}
+
+	// without a client, reading the template would panic
+	m := &Manager{}
+
+	err := m.applyTemplate(context.Background(), gameServer, map[string]any{
+		"templateRef":    map[string]any{"name": "survival"},
+		"deletionPolicy": "Delete",
+	})
+	if err != nil {
+		t.Fatalf("applyTemplate: %v", err)
+	}
+
+	if gameServer.Spec.GameType != "minecraft-java" || gameServer.Spec.DeletionPolicy != "Delete" {
+		t.Errorf("spec = %+v, want the deployed template under the GameServer's own fields", gameServer.Spec)
+	}
+
+	if gameServer.Status.Template != deployed {
+		t.Errorf("template = %+v, want the deployed one kept", gameServer.Status.Template)
+	}
+}
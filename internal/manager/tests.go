@@ -0,0 +1,117 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+// maxTestLogs is how much of the failed test pod logs is kept in the
+// ChartTestsPassed condition message.
+const maxTestLogs = 512
+
+// runChartTests runs the Helm tests of a deployed release and records the
+// outcome in the ChartTestsPassed condition. The logs of failed test pods are
+// attached to a Warning Event. Failing tests don't roll the release back.
+func (m *Manager) runChartTests(ctx context.Context, actionConfig *action.Configuration, gameServer *crds.GameServer, releaseName string) {
+	if !gameServer.Spec.HelmChart.RunTests {
+		return
+	}
+
+	tester := action.NewReleaseTesting(actionConfig)
+	tester.Namespace = gameServer.Namespace
+	tester.Timeout = helmTimeout(gameServer)
+
+	rel, err := tester.Run(releaseName)
+	if err == nil {
+		m.logger.Info("Chart tests passed", zap.String("ReleaseName", releaseName))
+		m.setTestsCondition(ctx, gameServer, ConditionTrue, "TestsSucceeded", fmt.Sprintf("%d test(s) passed", countTestHooks(rel)))
+
+		return
+	}
+
+	m.logger.Error("Chart tests failed", zap.String("ReleaseName", releaseName), zap.Error(err))
+
+	message := err.Error()
+
+	failed := failedTestHooks(rel)
+	if len(failed) > 0 {
+		var logs bytes.Buffer
+
+		tester.Filters[action.IncludeNameFilter] = failed
+		if logErr := tester.GetPodLogs(&logs, rel); logErr != nil {
+			m.logger.Error("Failed to fetch test pod logs", zap.String("ReleaseName", releaseName), zap.Error(logErr))
+		}
+
+		if logs.Len() > 0 {
+			m.recordEvent(ctx, gameServer, EventTypeWarning, "ChartTestsFailed", logs.String())
+			message = fmt.Sprintf("%s: %s", message, tailString(strings.TrimSpace(logs.String()), maxTestLogs))
+		}
+	}
+
+	m.setTestsCondition(ctx, gameServer, ConditionFalse, "TestsFailed", message)
+}
+
+func (m *Manager) setTestsCondition(ctx context.Context, gameServer *crds.GameServer, condStatus, reason, message string) {
+	err := m.updateStatus(ctx, gameServer.Namespace, gameServer.Name, func(status *crds.GameServerStatus) {
+		setCondition(status, ConditionChartTestsPassed, condStatus, reason, message)
+	})
+	if err != nil {
+		m.logger.Error("Failed to update GameServer status", zap.String("Name", gameServer.Name), zap.Error(err))
+	}
+}
+
+// failedTestHooks returns the names of the test hooks whose last run failed.
+func failedTestHooks(rel *release.Release) []string {
+	if rel == nil {
+		return nil
+	}
+
+	var failed []string
+
+	for _, hook := range rel.Hooks {
+		if isTestHook(hook) && hook.LastRun.Phase == release.HookPhaseFailed {
+			failed = append(failed, hook.Name)
+		}
+	}
+
+	return failed
+}
+
+func countTestHooks(rel *release.Release) int {
+	count := 0
+
+	for _, hook := range rel.Hooks {
+		if isTestHook(hook) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func isTestHook(hook *release.Hook) bool {
+	for _, event := range hook.Events {
+		if event == release.HookTest {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tailString keeps the last limit bytes of s.
+func tailString(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+
+	return "..." + s[len(s)-limit+3:]
+}
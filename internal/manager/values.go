@@ -0,0 +1,361 @@
+package manager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+const (
+	defaultValuesKey = "values.yaml"
+
+	// namespaceDefaultsConfigMap is the ConfigMap holding the default values of
+	// every GameServer in its namespace, under defaultValuesKey
+	namespaceDefaultsConfigMap = "gameserver-defaults"
+)
+
+var (
+	secretResource    = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	configMapResource = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+)
+
+// valuesLayer is one source of chart values. Layers are merged in order, later
+// layers taking precedence.
+type valuesLayer struct {
+	source string
+	values map[string]any
+	// sensitive layers are redacted wherever values are published
+	sensitive bool
+}
+
+// resolvedValues are the values a release gets deployed with.
+type resolvedValues struct {
+	values map[string]any
+	// hash is the content hash of values
+	hash string
+	// conflicts lists typed spec fields shadowed by explicit chart values
+	conflicts []string
+	// layers are all the layers values were merged from, starting with the
+	// chart defaults Helm merges in itself
+	layers []valuesLayer
+}
+
+// resolveValues builds the values a GameServer's release is deployed with.
+// From lowest to highest precedence, the layers are:
+//
+//   - the operator-wide defaults file
+//   - the defaults of the GameDefinition
+//   - the defaults of the namespace
+//   - every valuesFrom reference, in order
+//   - the typed spec fields mapped for the game
+//   - the RCON password of the game's console
+//   - valuesOverride
+//
+// Chart defaults sit beneath all of them, and are left for Helm to merge.
+func (m *Manager) resolveValues(ctx context.Context, gameServer *crds.GameServer, g *game) (*resolvedValues, error) {
+	layers := []valuesLayer{}
+
+	if m.options.DefaultValuesFile != "" {
+		data, err := os.ReadFile(m.options.DefaultValuesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read operator defaults: %w", err)
+		}
+
+		layer, err := parseValuesLayer("operator defaults "+m.options.DefaultValuesFile, string(data))
+		if err != nil {
+			return nil, err
+		}
+
+		layers = append(layers, layer)
+	}
+
+	if g.definition != nil {
+		layer, err := parseValuesLayer("GameDefinition "+g.definition.Name, g.definition.Spec.DefaultValues)
+		if err != nil {
+			return nil, err
+		}
+
+		layers = append(layers, layer)
+	}
+
+	namespaceDefaults, found, err := m.readValuesReference(ctx, gameServer.Namespace, "ConfigMap", namespaceDefaultsConfigMap, defaultValuesKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if found {
+		layer, err := parseValuesLayer(fmt.Sprintf("namespace defaults ConfigMap %s/%s", gameServer.Namespace, namespaceDefaultsConfigMap), namespaceDefaults)
+		if err != nil {
+			return nil, err
+		}
+
+		layers = append(layers, layer)
+	}
+
+	userValues := make(map[string]any)
+
+	for _, ref := range gameServer.Spec.HelmChart.ValuesFrom {
+		layer, err := m.readValuesLayer(ctx, gameServer.Namespace, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		if layer != nil {
+			layers = append(layers, *layer)
+			mergeValues(userValues, layer.values)
+		}
+	}
+
+	overrideLayer, err := parseValuesLayer("valuesOverride", gameServer.Spec.HelmChart.ValuesOverride)
+	if err != nil {
+		return nil, err
+	}
+
+	mergeValues(userValues, overrideLayer.values)
+
+	spec, err := g.effectiveSpec(gameServer.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	mapping, err := g.valueMapping()
+	if err != nil {
+		return nil, err
+	}
+
+	specLayers, conflicts, err := mapSpecValues(mapping, &spec, userValues)
+	if err != nil {
+		return nil, err
+	}
+
+	rconLayer, err := m.rconPasswordLayer(ctx, gameServer, mapping, userValues)
+	if err != nil {
+		return nil, err
+	}
+
+	if rconLayer != nil {
+		specLayers = append(specLayers, *rconLayer)
+	}
+
+	layers = append(append(layers, specLayers...), overrideLayer)
+
+	values := make(map[string]any)
+	for _, layer := range layers {
+		mergeValues(values, layer.values)
+	}
+
+	// the chart is part of the hash so that switching chart versions
+	// triggers an upgrade as well
+	hashed := map[string]any{
+		"chart":  g.chart.Name() + "-" + g.chart.Metadata.Version,
+		"values": values,
+	}
+
+	// patches change what is deployed as much as values do
+	if gameServer.Spec.PostRender != nil && len(gameServer.Spec.PostRender.Patches) > 0 {
+		hashed["postRender"] = gameServer.Spec.PostRender.Patches
+	}
+
+	hash, err := hashValues(hashed)
+	if err != nil {
+		return nil, err
+	}
+
+	chartLayer := valuesLayer{
+		source: fmt.Sprintf("chart defaults %s-%s", g.chart.Name(), g.chart.Metadata.Version),
+		values: g.chart.Values,
+	}
+
+	return &resolvedValues{
+		values:    values,
+		hash:      hash,
+		conflicts: conflicts,
+		layers:    append([]valuesLayer{chartLayer}, layers...),
+	}, nil
+}
+
+// parseValuesLayer parses a YAML values document into a layer.
+func parseValuesLayer(source, document string) (valuesLayer, error) {
+	values := make(map[string]any)
+
+	if err := yaml.Unmarshal([]byte(document), &values); err != nil {
+		return valuesLayer{}, fmt.Errorf("failed to parse %s: %w", source, err)
+	}
+
+	return valuesLayer{source: source, values: values}, nil
+}
+
+// readValuesLayer reads a single valuesFrom reference. The layer is nil when
+// an optional reference is missing.
+func (m *Manager) readValuesLayer(ctx context.Context, namespace string, ref crds.ValuesReference) (*valuesLayer, error) {
+	key := ref.Key
+	if key == "" {
+		key = defaultValuesKey
+	}
+
+	content, found, err := m.readValuesReference(ctx, namespace, ref.Kind, ref.Name, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		if ref.Optional {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("%s %s/%s has no key %s", ref.Kind, namespace, ref.Name, key)
+	}
+
+	return valuesFromLayer(namespace, ref, key, content)
+}
+
+// valuesFromLayer turns the content of a valuesFrom reference into a layer.
+// Layers read from Secrets are sensitive.
+func valuesFromLayer(namespace string, ref crds.ValuesReference, key, content string) (*valuesLayer, error) {
+	source := fmt.Sprintf("valuesFrom %s %s/%s key %s", ref.Kind, namespace, ref.Name, key)
+	sensitive := ref.Kind == "Secret"
+
+	if ref.TargetPath != "" {
+		values := make(map[string]any)
+		if err := setValuePath(values, ref.TargetPath, content); err != nil {
+			return nil, err
+		}
+
+		return &valuesLayer{source: source, values: values, sensitive: sensitive}, nil
+	}
+
+	layer, err := parseValuesLayer(source, content)
+	if err != nil {
+		return nil, err
+	}
+
+	layer.sensitive = sensitive
+
+	return &layer, nil
+}
+
+// readValuesReference returns the content of key in the referenced Secret or
+// ConfigMap, and whether the object and key exist.
+func (m *Manager) readValuesReference(ctx context.Context, namespace, kind, name, key string) (string, bool, error) {
+	var resource schema.GroupVersionResource
+
+	switch kind {
+	case "Secret":
+		resource = secretResource
+	case "ConfigMap":
+		resource = configMapResource
+	default:
+		return "", false, fmt.Errorf("unsupported valuesFrom kind %q", kind)
+	}
+
+	obj, err := m.k8sClient.Resource(resource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get %s %s/%s: %w", kind, namespace, name, err)
+	}
+
+	content, found, err := unstructured.NestedString(obj.Object, "data", key)
+	if err != nil || !found {
+		return "", false, err
+	}
+
+	if kind == "Secret" {
+		decoded, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to decode %s of Secret %s/%s: %w", key, namespace, name, err)
+		}
+
+		content = string(decoded)
+	}
+
+	return content, true, nil
+}
+
+// referencesValuesSource reports whether a GameServer pulls values from the
+// given object.
+func referencesValuesSource(gameServer *crds.GameServer, kind, name string) bool {
+	for _, ref := range gameServer.Spec.HelmChart.ValuesFrom {
+		if ref.Kind == kind && ref.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergeValues deep merges src into dst, with src taking precedence. Tables
+// are copied out of src, so later merges into dst never write through to it.
+func mergeValues(dst, src map[string]any) map[string]any {
+	for key, val := range src {
+		srcTable, srcIsTable := val.(map[string]any)
+		dstTable, dstIsTable := dst[key].(map[string]any)
+
+		if srcIsTable {
+			if !dstIsTable {
+				dstTable = make(map[string]any, len(srcTable))
+			}
+
+			dst[key] = mergeValues(dstTable, srcTable)
+
+			continue
+		}
+
+		dst[key] = val
+	}
+
+	return dst
+}
+
+// setValuePath sets value at a dot separated path, creating tables on the way.
+func setValuePath(values map[string]any, path string, value any) error {
+	keys := strings.Split(path, ".")
+	table := values
+
+	for i, key := range keys[:len(keys)-1] {
+		next, ok := table[key]
+		if !ok || next == nil {
+			next = make(map[string]any)
+			table[key] = next
+		}
+
+		nextTable, ok := next.(map[string]any)
+		if !ok {
+			return fmt.Errorf("cannot set %s: %s is not a table", path, strings.Join(keys[:i+1], "."))
+		}
+
+		table = nextTable
+	}
+
+	table[keys[len(keys)-1]] = value
+
+	return nil
+}
+
+// hashValues returns a stable content hash of a set of values.
+func hashValues(values map[string]any) (string, error) {
+	// encoding/json sorts map keys, so equal values always hash the same
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
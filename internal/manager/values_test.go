@@ -0,0 +1,185 @@
+package manager
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/chart"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+func TestSecretValuesAreRedacted(t *testing.T) {
+	secretRef := crds.ValuesReference{Kind: "Secret", Name: "db", TargetPath: "database.password"}
+
+	secret, err := valuesFromLayer("games", secretRef, "password", "hunter2")
+	if err != nil {
+		t.Fatalf("valuesFromLayer: %v", err)
+	}
+
+	parsedRef := crds.ValuesReference{Kind: "Secret", Name: "extra"}
+
+	parsed, err := valuesFromLayer("games", parsedRef, defaultValuesKey, "server:\n  motd: secret motd\n")
+	if err != nil {
+		t.Fatalf("valuesFromLayer: %v", err)
+	}
+
+	configMapRef := crds.ValuesReference{Kind: "ConfigMap", Name: "settings", TargetPath: "server.difficulty"}
+
+	configMap, err := valuesFromLayer("games", configMapRef, "difficulty", "hard")
+	if err != nil {
+		t.Fatalf("valuesFromLayer: %v", err)
+	}
+
+	resolved := &resolvedValues{layers: []valuesLayer{*secret, *parsed, *configMap}}
+
+	merged, sources := resolved.provenance()
+
+	for _, path := range [][]string{{"database", "password"}, {"server", "motd"}} {
+		value, _ := lookupValue(merged, path)
+		if value != redactedValue {
+			t.Errorf("%s = %v, want it redacted", strings.Join(path, "."), value)
+		}
+	}
+
+	if value, _ := lookupValue(merged, []string{"server", "difficulty"}); value != "hard" {
+		t.Errorf("server.difficulty = %v, want hard", value)
+	}
+
+	if source := sources["database.password"]; source != secret.source {
+		t.Errorf("source of database.password = %q, want %q", source, secret.source)
+	}
+
+	annotated, err := resolved.annotatedYAML()
+	if err != nil {
+		t.Fatalf("annotatedYAML: %v", err)
+	}
+
+	if strings.Contains(annotated, "hunter2") || strings.Contains(annotated, "secret motd") {
+		t.Errorf("annotated values leak a Secret value:\n%s", annotated)
+	}
+}
+
+func TestSyncValuesSourceSkipsUnreferenced(t *testing.T) {
+	instanceMap, _ := crds.NewInstanceMap()
+	_ = instanceMap.Create(&crds.GameServer{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "games", Name: "survival"},
+		Spec: crds.GameServerSpec{HelmChart: crds.HelmChart{
+			ValuesFrom: []crds.ValuesReference{{Kind: "Secret", Name: "db"}},
+		}},
+	})
+
+	// without a client, listing GameServers would panic
+	m := &Manager{instanceMap: instanceMap, logger: zap.NewNop()}
+
+	tests := map[string]struct {
+		kind, namespace, name string
+		labels                map[string]string
+	}{
+		"unreferenced":    {kind: "Secret", namespace: "games", name: "other"},
+		"other kind":      {kind: "ConfigMap", namespace: "games", name: "db"},
+		"other namespace": {kind: "Secret", namespace: "lobby", name: "db"},
+		"helm release":    {kind: "Secret", namespace: "games", name: "db", labels: map[string]string{"owner": "helm"}},
+		"operator's own":  {kind: "Secret", namespace: "games", name: "db", labels: map[string]string{managedByLabel: managedByValue}},
+	}
+
+	for name, test := range tests {
+		source := &unstructured.Unstructured{}
+		source.SetNamespace(test.namespace)
+		source.SetName(test.name)
+		source.SetLabels(test.labels)
+
+		if err := m.SyncValuesSource(context.Background(), test.kind, source); err != nil {
+			t.Errorf("%s: SyncValuesSource: %v", name, err)
+		}
+	}
+}
+
+func TestResolveValuesLayering(t *testing.T) {
+	defaults := filepath.Join(t.TempDir(), "defaults.yaml")
+	if err := os.WriteFile(defaults, []byte("operator: operator\ndefinition: operator\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, _ := newFakeManager(t,
+		testObject("v1", "ConfigMap", "games", namespaceDefaultsConfigMap, map[string]any{
+			"data": map[string]any{defaultValuesKey: "namespace: namespace\nvaluesFrom: namespace\n"},
+		}),
+		testObject("v1", "ConfigMap", "games", "settings", map[string]any{
+			"data": map[string]any{defaultValuesKey: "valuesFrom: valuesFrom\noverride: valuesFrom\n"},
+		}),
+		testObject("v1", "Secret", "games", "db", map[string]any{
+			"data": map[string]any{"password": base64.StdEncoding.EncodeToString([]byte("hunter2"))},
+		}),
+	)
+	m.options.DefaultValuesFile = defaults
+
+	g := &game{
+		chart: &chart.Chart{Metadata: &chart.Metadata{Name: "survival", Version: "1.0.0"}},
+		definition: &crds.GameDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "minecraft"},
+			Spec:       crds.GameDefinitionSpec{DefaultValues: "definition: definition\nnamespace: definition\n"},
+		},
+	}
+
+	gameServer := &crds.GameServer{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "games", Name: "survival"},
+		Spec: crds.GameServerSpec{HelmChart: crds.HelmChart{
+			ValuesFrom: []crds.ValuesReference{
+				{Kind: "ConfigMap", Name: "settings"},
+				{Kind: "Secret", Name: "db", Key: "password", TargetPath: "database.password"},
+				{Kind: "ConfigMap", Name: "missing", Optional: true},
+			},
+			ValuesOverride: "override: override\n",
+		}},
+	}
+
+	resolved, err := m.resolveValues(context.Background(), gameServer, g)
+	if err != nil {
+		t.Fatalf("resolveValues: %v", err)
+	}
+
+	// each layer sets its own key and the key of the layer above, which wins
+	for _, key := range []string{"operator", "definition", "namespace", "valuesFrom", "override"} {
+		if value := resolved.values[key]; value != key {
+			t.Errorf("%s = %v, want it from the %s layer", key, value, key)
+		}
+	}
+
+	if value, _ := lookupValue(resolved.values, []string{"database", "password"}); value != "hunter2" {
+		t.Errorf("database.password = %v, want the Secret's value", value)
+	}
+
+	sources := make([]string, 0, len(resolved.layers))
+	for _, layer := range resolved.layers {
+		sources = append(sources, layer.source)
+	}
+
+	want := []string{
+		"chart defaults survival-1.0.0",
+		"operator defaults " + defaults,
+		"GameDefinition minecraft",
+		"namespace defaults ConfigMap games/" + namespaceDefaultsConfigMap,
+		"valuesFrom ConfigMap games/settings key " + defaultValuesKey,
+		"valuesFrom Secret games/db key password",
+		"valuesOverride",
+	}
+
+	if !reflect.DeepEqual(sources, want) {
+		t.Errorf("layers = %q, want %q", sources, want)
+	}
+
+	gameServer.Spec.HelmChart.ValuesFrom[2].Optional = false
+
+	if _, err := m.resolveValues(context.Background(), gameServer, g); err == nil {
+		t.Error("resolveValues with a missing required reference succeeded")
+	}
+}
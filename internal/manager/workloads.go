@@ -0,0 +1,355 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+	"github.com/Sackbuoy/gameserver-operator/internal/query"
+)
+
+// observeInterval is the least time between two observations of the
+// workloads of a release.
+const observeInterval = 15 * time.Second
+
+var (
+	deploymentResource  = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	statefulSetResource = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}
+	podResource         = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+)
+
+// releaseObject is a resource found in a release manifest.
+type releaseObject struct {
+	kind string
+	name string
+}
+
+// releaseManifest caches the objects of a release revision, revisions never
+// change once deployed.
+type releaseManifest struct {
+	revision int
+	objects  []releaseObject
+}
+
+// observeRelease refreshes the status a GameServer reports about the
+// workloads and Services of its deployed release and about its game, at most
+// once every observeInterval unless force is set.
+func (m *Manager) observeRelease(ctx context.Context, gameServer *crds.GameServer, releaseName string, force bool) {
+	key := gameServer.Namespace + "/" + releaseName
+
+	if last, ok := m.lastObserved.Load(key); ok && !force {
+		if observed, _ := last.(time.Time); time.Since(observed) < observeInterval {
+			return
+		}
+	}
+
+	m.lastObserved.Store(key, time.Now())
+
+	actionConfig, err := m.newActionConfig(gameServer.Namespace, m.storageDriver(gameServer))
+	if err != nil {
+		return
+	}
+
+	objects, err := m.releaseObjects(actionConfig, gameServer.Namespace, releaseName)
+	if err != nil {
+		m.logger.Error("Failed to read release manifest", zap.String("ReleaseName", releaseName), zap.Error(err))
+
+		return
+	}
+
+	deployment, err := m.observeWorkloads(ctx, gameServer.Namespace, objects)
+	if err != nil {
+		m.logger.Error("Failed to observe workloads", zap.String("ReleaseName", releaseName), zap.Error(err))
+
+		return
+	}
+
+	networking, connectAddress, err := m.observeServices(ctx, gameServer.Namespace, objects)
+	if err != nil {
+		m.logger.Error("Failed to observe services", zap.String("ReleaseName", releaseName), zap.Error(err))
+
+		return
+	}
+
+	var (
+		game     *crds.GameStatus
+		probeErr error
+	)
+
+	if probes(gameServer) {
+		probeCtx, cancel := context.WithTimeout(ctx, query.DefaultTimeout)
+		game, probeErr = probeGame(probeCtx, gameServer, networking)
+		cancel()
+	}
+
+	err = m.updateStatus(ctx, gameServer.Namespace, gameServer.Name, func(status *crds.GameServerStatus) {
+		status.Deployment = deployment
+		status.Networking = networking
+		status.ConnectAddress = connectAddress
+
+		if probes(gameServer) {
+			recordProbe(status, game, probeErr)
+		}
+	})
+	if err != nil {
+		m.logger.Error("Failed to update GameServer status", zap.String("Name", gameServer.Name), zap.Error(err))
+	}
+
+	// player lists are synced over the console of a game that answers
+	if probes(gameServer) && probeErr == nil && !m.isDryRun(gameServer) {
+		observed := *gameServer
+		observed.Status.Networking = networking
+		m.syncPlayers(ctx, &observed)
+	}
+}
+
+// forgetRelease drops what was observed about a release.
+func (m *Manager) forgetRelease(namespace, releaseName string) {
+	m.lastObserved.Delete(namespace + "/" + releaseName)
+	m.manifestCache.Delete(namespace + "/" + releaseName)
+}
+
+// releaseObjects returns the objects in the manifest of the latest revision
+// of a release.
+func (m *Manager) releaseObjects(actionConfig *action.Configuration, namespace, releaseName string) ([]releaseObject, error) {
+	rel, err := actionConfig.Releases.Last(releaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	key := namespace + "/" + releaseName
+
+	if cached, ok := m.manifestCache.Load(key); ok {
+		if manifest, _ := cached.(*releaseManifest); manifest.revision == rel.Version {
+			return manifest.objects, nil
+		}
+	}
+
+	var objects []releaseObject
+
+	for _, document := range releaseutil.SplitManifests(rel.Manifest) {
+		node, err := yaml.Parse(document)
+		if err != nil || node.GetKind() == "" {
+			continue
+		}
+
+		objects = append(objects, releaseObject{kind: node.GetKind(), name: node.GetName()})
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].kind != objects[j].kind {
+			return objects[i].kind < objects[j].kind
+		}
+
+		return objects[i].name < objects[j].name
+	})
+
+	m.manifestCache.Store(key, &releaseManifest{revision: rel.Version, objects: objects})
+
+	return objects, nil
+}
+
+// observeWorkloads sums up the Deployments and StatefulSets among objects,
+// along with the pods they select.
+func (m *Manager) observeWorkloads(ctx context.Context, namespace string, objects []releaseObject) (*crds.DeploymentStatus, error) {
+	deployment := &crds.DeploymentStatus{Available: true}
+
+	var selectors []*metav1.LabelSelector
+
+	for _, object := range objects {
+		var (
+			workload crds.WorkloadStatus
+			selector *metav1.LabelSelector
+			err      error
+		)
+
+		switch object.kind {
+		case "Deployment":
+			workload, selector, err = m.observeDeployment(ctx, namespace, object.name, deployment)
+		case "StatefulSet":
+			workload, selector, err = m.observeStatefulSet(ctx, namespace, object.name, deployment)
+		default:
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if workload.AvailableReplicas < workload.DesiredReplicas {
+			deployment.Available = false
+		}
+
+		deployment.Workloads = append(deployment.Workloads, workload)
+		selectors = append(selectors, selector)
+	}
+
+	if len(deployment.Workloads) == 0 {
+		deployment.Available = false
+
+		return deployment, nil
+	}
+
+	pods, err := m.workloadPods(ctx, namespace, selectors)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastTermination time.Time
+
+	for _, pod := range pods {
+		podStatus := observePod(pod)
+		deployment.RestartCount += podStatus.RestartCount
+
+		if podStatus.LastTerminationTime != nil && podStatus.LastTerminationTime.After(lastTermination) {
+			lastTermination = podStatus.LastTerminationTime.Time
+			deployment.LastTerminationReason = podStatus.LastTerminationReason
+		}
+
+		deployment.Pods = append(deployment.Pods, podStatus)
+	}
+
+	return deployment, nil
+}
+
+func (m *Manager) observeDeployment(ctx context.Context, namespace, name string, deployment *crds.DeploymentStatus) (crds.WorkloadStatus, *metav1.LabelSelector, error) {
+	obj, err := m.k8sClient.Resource(deploymentResource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return crds.WorkloadStatus{}, nil, fmt.Errorf("failed to get Deployment %s: %w", name, err)
+	}
+
+	var workload appsv1.Deployment
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &workload); err != nil {
+		return crds.WorkloadStatus{}, nil, err
+	}
+
+	deployment.Replicas += workload.Status.Replicas
+	deployment.ReadyReplicas += workload.Status.ReadyReplicas
+	deployment.UpdatedReplicas += workload.Status.UpdatedReplicas
+
+	return crds.WorkloadStatus{
+		Kind:              "Deployment",
+		Name:              name,
+		DesiredReplicas:   desiredReplicas(workload.Spec.Replicas),
+		ReadyReplicas:     workload.Status.ReadyReplicas,
+		AvailableReplicas: workload.Status.AvailableReplicas,
+	}, workload.Spec.Selector, nil
+}
+
+func (m *Manager) observeStatefulSet(ctx context.Context, namespace, name string, deployment *crds.DeploymentStatus) (crds.WorkloadStatus, *metav1.LabelSelector, error) {
+	obj, err := m.k8sClient.Resource(statefulSetResource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return crds.WorkloadStatus{}, nil, fmt.Errorf("failed to get StatefulSet %s: %w", name, err)
+	}
+
+	var workload appsv1.StatefulSet
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &workload); err != nil {
+		return crds.WorkloadStatus{}, nil, err
+	}
+
+	deployment.Replicas += workload.Status.Replicas
+	deployment.ReadyReplicas += workload.Status.ReadyReplicas
+	deployment.UpdatedReplicas += workload.Status.UpdatedReplicas
+
+	return crds.WorkloadStatus{
+		Kind:              "StatefulSet",
+		Name:              name,
+		DesiredReplicas:   desiredReplicas(workload.Spec.Replicas),
+		ReadyReplicas:     workload.Status.ReadyReplicas,
+		AvailableReplicas: workload.Status.AvailableReplicas,
+	}, workload.Spec.Selector, nil
+}
+
+// desiredReplicas defaults an unset replica count the way the API server does.
+func desiredReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+
+	return *replicas
+}
+
+// workloadPods lists the pods selected by any of selectors, each pod once.
+func (m *Manager) workloadPods(ctx context.Context, namespace string, selectors []*metav1.LabelSelector) ([]corev1.Pod, error) {
+	seen := make(map[string]struct{})
+
+	var pods []corev1.Pod
+
+	for _, labelSelector := range selectors {
+		selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+
+		list, err := m.k8sClient.Resource(podResource).Namespace(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods: %w", err)
+		}
+
+		for _, item := range list.Items {
+			if _, ok := seen[item.GetName()]; ok {
+				continue
+			}
+
+			seen[item.GetName()] = struct{}{}
+
+			var pod corev1.Pod
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &pod); err != nil {
+				return nil, err
+			}
+
+			pods = append(pods, pod)
+		}
+	}
+
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+
+	return pods, nil
+}
+
+// observePod summarizes the containers of a pod.
+func observePod(pod corev1.Pod) crds.PodStatus {
+	podStatus := crds.PodStatus{
+		Name:  pod.Name,
+		Phase: string(pod.Status.Phase),
+		Ready: len(pod.Status.ContainerStatuses) > 0,
+	}
+
+	for _, container := range pod.Status.ContainerStatuses {
+		podStatus.RestartCount += container.RestartCount
+
+		if !container.Ready {
+			podStatus.Ready = false
+		}
+
+		if waiting := container.State.Waiting; waiting != nil && podStatus.WaitingReason == "" {
+			podStatus.WaitingReason = waiting.Reason
+		}
+
+		terminated := container.LastTerminationState.Terminated
+		if terminated == nil {
+			continue
+		}
+
+		if podStatus.LastTerminationTime == nil || terminated.FinishedAt.After(podStatus.LastTerminationTime.Time) {
+			finishedAt := terminated.FinishedAt
+			podStatus.LastTerminationTime = &finishedAt
+			podStatus.LastTerminationReason = terminated.Reason
+			podStatus.LastTerminationExitCode = terminated.ExitCode
+		}
+	}
+
+	return podStatus
+}
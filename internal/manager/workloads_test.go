@@ -0,0 +1,160 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestObservePod(t *testing.T) {
+	earlier := metav1.NewTime(time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC))
+	later := metav1.NewTime(earlier.Add(time.Hour))
+
+	terminated := func(reason string, exitCode int32, at metav1.Time) corev1.ContainerState {
+		return corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: reason, ExitCode: exitCode, FinishedAt: at}}
+	}
+
+	tests := []struct {
+		name       string
+		containers []corev1.ContainerStatus
+		want       podSummary
+	}{
+		{
+			name: "no containers yet",
+			want: podSummary{ready: false},
+		},
+		{
+			name:       "ready",
+			containers: []corev1.ContainerStatus{{Ready: true}, {Ready: true}},
+			want:       podSummary{ready: true},
+		},
+		{
+			name: "crash looping",
+			containers: []corev1.ContainerStatus{
+				{Ready: true, RestartCount: 1, LastTerminationState: terminated("Error", 1, earlier)},
+				{
+					RestartCount:         4,
+					State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+					LastTerminationState: terminated("OOMKilled", 137, later),
+				},
+			},
+			want: podSummary{restarts: 5, waiting: "CrashLoopBackOff", termination: "OOMKilled", exitCode: 137},
+		},
+	}
+
+	for _, test := range tests {
+		pod := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "survival-0"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: test.containers},
+		}
+
+		got := observePod(pod)
+
+		summary := podSummary{
+			ready:       got.Ready,
+			restarts:    got.RestartCount,
+			waiting:     got.WaitingReason,
+			termination: got.LastTerminationReason,
+			exitCode:    got.LastTerminationExitCode,
+		}
+
+		if summary != test.want || got.Name != "survival-0" || got.Phase != "Running" {
+			t.Errorf("%s: observePod = %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+type podSummary struct {
+	ready       bool
+	restarts    int32
+	waiting     string
+	termination string
+	exitCode    int32
+}
+
+func TestDesiredReplicas(t *testing.T) {
+	zero, three := int32(0), int32(3)
+
+	tests := []struct {
+		replicas *int32
+		want     int32
+	}{
+		{nil, 1},
+		{&zero, 0},
+		{&three, 3},
+	}
+
+	for _, test := range tests {
+		if got := desiredReplicas(test.replicas); got != test.want {
+			t.Errorf("desiredReplicas(%v) = %d, want %d", test.replicas, got, test.want)
+		}
+	}
+}
+
+func TestObserveWorkloads(t *testing.T) {
+	two := int32(2)
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "survival"}}
+
+	deployment := &appsv1.Deployment{
+		Spec:   appsv1.DeploymentSpec{Selector: selector},
+		Status: appsv1.DeploymentStatus{Replicas: 1, ReadyReplicas: 1, AvailableReplicas: 1, UpdatedReplicas: 1},
+	}
+
+	statefulSet := &appsv1.StatefulSet{
+		Spec:   appsv1.StatefulSetSpec{Replicas: &two, Selector: selector},
+		Status: appsv1.StatefulSetStatus{Replicas: 2, ReadyReplicas: 1, AvailableReplicas: 1, UpdatedReplicas: 2},
+	}
+
+	pod := func(name, app string, restarts int32) *unstructured.Unstructured {
+		return toObject(t, "v1", "Pod", name, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": app}},
+			Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Ready: true, RestartCount: restarts}}},
+		})
+	}
+
+	m, _ := newFakeManager(t,
+		toObject(t, "apps/v1", "Deployment", "survival", deployment),
+		toObject(t, "apps/v1", "StatefulSet", "survival-db", statefulSet),
+		pod("survival-a", "survival", 1),
+		pod("survival-b", "survival", 2),
+		pod("creative-a", "creative", 7),
+	)
+
+	objects := []releaseObject{
+		{kind: "Deployment", name: "survival"},
+		{kind: "Service", name: "survival"},
+		{kind: "StatefulSet", name: "survival-db"},
+	}
+
+	status, err := m.observeWorkloads(context.Background(), "games", objects)
+	if err != nil {
+		t.Fatalf("observeWorkloads: %v", err)
+	}
+
+	if status.Available {
+		t.Error("available with a StatefulSet short of replicas")
+	}
+
+	if status.Replicas != 3 || status.ReadyReplicas != 2 || status.UpdatedReplicas != 3 {
+		t.Errorf("replicas = %d/%d/%d, want 3/2/3", status.Replicas, status.ReadyReplicas, status.UpdatedReplicas)
+	}
+
+	if len(status.Workloads) != 2 || status.Workloads[1].DesiredReplicas != 2 {
+		t.Errorf("workloads = %+v, want the Deployment and the StatefulSet", status.Workloads)
+	}
+
+	// both workloads select the same pods, which are counted once
+	if len(status.Pods) != 2 || status.RestartCount != 3 {
+		t.Errorf("pods = %+v with %d restarts, want survival-a and survival-b with 3", status.Pods, status.RestartCount)
+	}
+
+	empty, err := m.observeWorkloads(context.Background(), "games", objects[1:2])
+	if err != nil || empty.Available {
+		t.Errorf("observeWorkloads without workloads = %+v, %v, want unavailable", empty, err)
+	}
+}
@@ -0,0 +1,323 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"time"
+)
+
+// A2S packet headers, see the Valve server queries documentation.
+const (
+	a2sInfoRequest   = 0x54
+	a2sPlayerRequest = 0x55
+	a2sInfoReply     = 0x49
+	a2sPlayerReply   = 0x44
+	a2sChallenge     = 0x41
+
+	a2sSinglePacket = -1
+	a2sSplitPacket  = -2
+
+	// maxA2SPacket is the largest datagram Source servers send
+	maxA2SPacket = 1400
+	// maxA2SChallenges bounds the challenges a server may answer with before
+	// the query gives up
+	maxA2SChallenges = 3
+)
+
+var a2sInfoPayload = append([]byte("Source Engine Query"), 0x00)
+
+// A2SInfo is what a Source engine server answers to A2S_INFO.
+type A2SInfo struct {
+	Protocol   byte
+	Name       string
+	Map        string
+	Folder     string
+	Game       string
+	AppID      uint16
+	Players    int
+	MaxPlayers int
+	Bots       int
+	// ServerType is 'd' for dedicated, 'l' for listen and 'p' for a proxy
+	ServerType byte
+	// Environment is 'l' for Linux, 'w' for Windows and 'm' for macOS
+	Environment byte
+	Password    bool
+	VAC         bool
+	Version     string
+}
+
+// A2SPlayer is a player listed in an A2S_PLAYER answer.
+type A2SPlayer struct {
+	Name     string
+	Score    int32
+	Duration time.Duration
+}
+
+// QueryA2SInfo sends A2S_INFO to the Source engine query port at address
+// (host:port), answering the challenge the server may ask for.
+func QueryA2SInfo(ctx context.Context, address string) (*A2SInfo, error) {
+	conn, err := dial(ctx, "udp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	reply, err := a2sExchange(conn, a2sInfoRequest, a2sInfoPayload, nil, a2sInfoReply)
+	if err != nil {
+		return nil, fmt.Errorf("A2S_INFO to %s failed: %w", address, err)
+	}
+
+	return parseA2SInfo(reply)
+}
+
+// QueryA2SPlayers sends A2S_PLAYER to the Source engine query port at
+// address (host:port), answering the challenge the server asks for.
+func QueryA2SPlayers(ctx context.Context, address string) ([]A2SPlayer, error) {
+	conn, err := dial(ctx, "udp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// the player query is always challenged, -1 asks for the challenge
+	reply, err := a2sExchange(conn, a2sPlayerRequest, nil, []byte{0xFF, 0xFF, 0xFF, 0xFF}, a2sPlayerReply)
+	if err != nil {
+		return nil, fmt.Errorf("A2S_PLAYER to %s failed: %w", address, err)
+	}
+
+	return parseA2SPlayers(reply)
+}
+
+// a2sExchange sends a request and returns the payload of the reply with
+// header want, resending the request with the challenge the server answers
+// with in its place.
+func a2sExchange(conn net.Conn, header byte, payload, challenge []byte, want byte) ([]byte, error) {
+	for range maxA2SChallenges {
+		request := []byte{0xFF, 0xFF, 0xFF, 0xFF, header}
+		request = append(request, payload...)
+		request = append(request, challenge...)
+
+		if _, err := conn.Write(request); err != nil {
+			return nil, err
+		}
+
+		reply, err := readA2SReply(conn)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(reply) == 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		switch reply[0] {
+		case want:
+			return reply[1:], nil
+		case a2sChallenge:
+			if len(reply) < 5 {
+				return nil, io.ErrUnexpectedEOF
+			}
+
+			challenge = reply[1:5]
+		default:
+			return nil, fmt.Errorf("unexpected reply header 0x%02x", reply[0])
+		}
+	}
+
+	return nil, errors.New("server kept answering with challenges")
+}
+
+// readA2SReply reads a reply, reassembling replies split over several
+// packets.
+func readA2SReply(conn net.Conn) ([]byte, error) {
+	buf := make([]byte, maxA2SPacket)
+
+	var (
+		parts [][]byte
+		id    int32
+		total int
+		got   int
+	)
+
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		reader := bytes.NewReader(buf[:n])
+
+		var kind int32
+		if err := binary.Read(reader, binary.LittleEndian, &kind); err != nil {
+			return nil, err
+		}
+
+		if kind == a2sSinglePacket {
+			reply := make([]byte, reader.Len())
+			_, _ = reader.Read(reply)
+
+			return reply, nil
+		}
+
+		if kind != a2sSplitPacket {
+			return nil, fmt.Errorf("unexpected packet header %d", kind)
+		}
+
+		// Source split header: id, total, number, size
+		var header struct {
+			ID     int32
+			Total  byte
+			Number byte
+			Size   int16
+		}
+
+		if err := binary.Read(reader, binary.LittleEndian, &header); err != nil {
+			return nil, err
+		}
+
+		if header.ID < 0 {
+			return nil, errors.New("compressed replies are not supported")
+		}
+
+		if parts == nil {
+			id, total = header.ID, int(header.Total)
+			parts = make([][]byte, total)
+		}
+
+		if header.ID != id || int(header.Number) >= total {
+			return nil, errors.New("malformed split reply")
+		}
+
+		if parts[header.Number] == nil {
+			part := make([]byte, reader.Len())
+			_, _ = reader.Read(part)
+			parts[header.Number] = part
+			got++
+		}
+
+		if got == total {
+			reply := bytes.Join(parts, nil)
+
+			// the reassembled payload carries the single packet header again
+			if len(reply) < 4 || int32(binary.LittleEndian.Uint32(reply)) != a2sSinglePacket {
+				return nil, errors.New("malformed split reply")
+			}
+
+			return reply[4:], nil
+		}
+	}
+}
+
+func parseA2SInfo(reply []byte) (*A2SInfo, error) {
+	reader := bytes.NewReader(reply)
+	info := &A2SInfo{}
+
+	var err error
+
+	read := func(target any) {
+		if err == nil {
+			err = binary.Read(reader, binary.LittleEndian, target)
+		}
+	}
+
+	readText := func(target *string) {
+		if err == nil {
+			*target, err = readCString(reader)
+		}
+	}
+
+	var players, maxPlayers, bots, password, vac byte
+
+	read(&info.Protocol)
+	readText(&info.Name)
+	readText(&info.Map)
+	readText(&info.Folder)
+	readText(&info.Game)
+	read(&info.AppID)
+	read(&players)
+	read(&maxPlayers)
+	read(&bots)
+	read(&info.ServerType)
+	read(&info.Environment)
+	read(&password)
+	read(&vac)
+	readText(&info.Version)
+
+	if err != nil {
+		return nil, fmt.Errorf("malformed A2S_INFO reply: %w", err)
+	}
+
+	info.Players = int(players)
+	info.MaxPlayers = int(maxPlayers)
+	info.Bots = int(bots)
+	info.Password = password == 1
+	info.VAC = vac == 1
+
+	return info, nil
+}
+
+func parseA2SPlayers(reply []byte) ([]A2SPlayer, error) {
+	reader := bytes.NewReader(reply)
+
+	count, err := reader.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("malformed A2S_PLAYER reply: %w", err)
+	}
+
+	players := make([]A2SPlayer, 0, count)
+
+	for range count {
+		var (
+			player   A2SPlayer
+			duration float32
+		)
+
+		// each player starts with an index byte that is always 0
+		if _, err = reader.ReadByte(); err == nil {
+			player.Name, err = readCString(reader)
+		}
+
+		if err == nil {
+			err = binary.Read(reader, binary.LittleEndian, &player.Score)
+		}
+
+		if err == nil {
+			err = binary.Read(reader, binary.LittleEndian, &duration)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("malformed A2S_PLAYER reply: %w", err)
+		}
+
+		if !math.IsNaN(float64(duration)) {
+			player.Duration = time.Duration(float64(duration) * float64(time.Second))
+		}
+
+		players = append(players, player)
+	}
+
+	return players, nil
+}
+
+func readCString(reader *bytes.Reader) (string, error) {
+	var value []byte
+
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return "", err
+		}
+
+		if b == 0x00 {
+			return string(value), nil
+		}
+
+		value = append(value, b)
+	}
+}
@@ -0,0 +1,233 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"net"
+	"testing"
+	"time"
+)
+
+var testChallenge = []byte{0x0A, 0x0B, 0x0C, 0x0D}
+
+// serveA2S answers every datagram sent to it with the datagrams respond
+// returns.
+func serveA2S(t *testing.T, respond func(request []byte) [][]byte) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, maxA2SPacket)
+
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			for _, datagram := range respond(append([]byte(nil), buf[:n]...)) {
+				_, _ = conn.WriteTo(datagram, addr)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+// challenged answers requests lacking the test challenge with it, and the
+// others with reply.
+func challenged(t *testing.T, header byte, reply ...[]byte) func([]byte) [][]byte {
+	return func(request []byte) [][]byte {
+		if !bytes.HasPrefix(request, []byte{0xFF, 0xFF, 0xFF, 0xFF, header}) {
+			t.Errorf("unexpected request % x", request)
+
+			return nil
+		}
+
+		if !bytes.HasSuffix(request, testChallenge) {
+			return [][]byte{single(append([]byte{a2sChallenge}, testChallenge...))}
+		}
+
+		return reply
+	}
+}
+
+func single(payload []byte) []byte {
+	return append([]byte{0xFF, 0xFF, 0xFF, 0xFF}, payload...)
+}
+
+// split cuts a reply into Source split packets, in the order given.
+func split(payload []byte, size int, order ...int) [][]byte {
+	whole := single(payload)
+
+	var parts [][]byte
+	for len(whole) > 0 {
+		n := min(size, len(whole))
+		parts = append(parts, whole[:n])
+		whole = whole[n:]
+	}
+
+	datagrams := make([][]byte, 0, len(order))
+
+	for _, number := range order {
+		var datagram bytes.Buffer
+		_ = binary.Write(&datagram, binary.LittleEndian, int32(a2sSplitPacket))
+		_ = binary.Write(&datagram, binary.LittleEndian, int32(1234))
+		datagram.WriteByte(byte(len(parts)))
+		datagram.WriteByte(byte(number))
+		_ = binary.Write(&datagram, binary.LittleEndian, int16(maxA2SPacket))
+		datagram.Write(parts[number])
+
+		datagrams = append(datagrams, datagram.Bytes())
+	}
+
+	return datagrams
+}
+
+func infoReply() []byte {
+	var reply bytes.Buffer
+
+	reply.WriteByte(a2sInfoReply)
+	reply.WriteByte(17)
+	reply.WriteString("Test Server\x00de_dust2\x00csgo\x00Counter-Strike 2\x00")
+	_ = binary.Write(&reply, binary.LittleEndian, uint16(730))
+	reply.Write([]byte{5, 32, 1, 'd', 'l', 0, 1})
+	reply.WriteString("1.40.1.1\x00")
+
+	return reply.Bytes()
+}
+
+func playerReply() []byte {
+	var reply bytes.Buffer
+
+	reply.WriteByte(a2sPlayerReply)
+	reply.WriteByte(2)
+
+	for _, player := range []struct {
+		name     string
+		score    int32
+		duration float32
+	}{
+		{"Alice", 12, 90.5},
+		{"Bob", -3, float32(math.NaN())},
+	} {
+		reply.WriteByte(0)
+		reply.WriteString(player.name + "\x00")
+		_ = binary.Write(&reply, binary.LittleEndian, player.score)
+		_ = binary.Write(&reply, binary.LittleEndian, player.duration)
+	}
+
+	return reply.Bytes()
+}
+
+func TestQueryA2SInfo(t *testing.T) {
+	for name, respond := range map[string]func([]byte) [][]byte{
+		"unchallenged": func([]byte) [][]byte { return [][]byte{single(infoReply())} },
+		"challenged":   challenged(t, a2sInfoRequest, single(infoReply())),
+	} {
+		info, err := QueryA2SInfo(context.Background(), serveA2S(t, respond))
+		if err != nil {
+			t.Errorf("%s: QueryA2SInfo: %v", name, err)
+
+			continue
+		}
+
+		want := A2SInfo{
+			Protocol: 17, Name: "Test Server", Map: "de_dust2", Folder: "csgo", Game: "Counter-Strike 2",
+			AppID: 730, Players: 5, MaxPlayers: 32, Bots: 1, ServerType: 'd', Environment: 'l',
+			Password: false, VAC: true, Version: "1.40.1.1",
+		}
+
+		if *info != want {
+			t.Errorf("%s: QueryA2SInfo = %+v, want %+v", name, *info, want)
+		}
+	}
+}
+
+func TestQueryA2SPlayers(t *testing.T) {
+	for name, reply := range map[string][][]byte{
+		"single": {single(playerReply())},
+		// out of order, with a part sent twice
+		"split": split(playerReply(), 10, 2, 0, 0, 3, 1),
+	} {
+		players, err := QueryA2SPlayers(context.Background(), serveA2S(t, challenged(t, a2sPlayerRequest, reply...)))
+		if err != nil {
+			t.Errorf("%s: QueryA2SPlayers: %v", name, err)
+
+			continue
+		}
+
+		if len(players) != 2 {
+			t.Fatalf("%s: QueryA2SPlayers = %+v, want 2 players", name, players)
+		}
+
+		if players[0].Name != "Alice" || players[0].Score != 12 || players[0].Duration != 90500*time.Millisecond {
+			t.Errorf("%s: first player = %+v", name, players[0])
+		}
+
+		if players[1].Name != "Bob" || players[1].Score != -3 || players[1].Duration != 0 {
+			t.Errorf("%s: second player = %+v", name, players[1])
+		}
+	}
+}
+
+func TestQueryA2SRejects(t *testing.T) {
+	info := infoReply()
+	players := playerReply()
+
+	compressed := split(infoReply(), 20, 0, 1)
+	compressed[0][7] |= 0x80
+
+	tests := map[string]struct {
+		players bool
+		respond func([]byte) [][]byte
+	}{
+		"truncated info": {
+			respond: func([]byte) [][]byte { return [][]byte{single(info[:len(info)-4])} },
+		},
+		"truncated players": {
+			players: true,
+			respond: challenged(t, a2sPlayerRequest, single(players[:len(players)-2])),
+		},
+		"truncated challenge": {
+			respond: func([]byte) [][]byte { return [][]byte{single([]byte{a2sChallenge, 0x01})} },
+		},
+		"endless challenges": {
+			respond: func(request []byte) [][]byte {
+				return [][]byte{single([]byte{a2sChallenge, byte(len(request)), 0, 0, 0})}
+			},
+		},
+		"unexpected header": {
+			respond: func([]byte) [][]byte { return [][]byte{single([]byte{0x6D})} },
+		},
+		"compressed split": {
+			respond: func([]byte) [][]byte { return compressed },
+		},
+	}
+
+	for name, test := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		address := serveA2S(t, test.respond)
+
+		var err error
+		if test.players {
+			_, err = QueryA2SPlayers(ctx, address)
+		} else {
+			_, err = QueryA2SInfo(ctx, address)
+		}
+
+		cancel()
+
+		if err == nil {
+			t.Errorf("%s: query accepted the reply", name)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+// Package query speaks the status protocols game servers answer, to tell
+// whether a game accepts players rather than just whether its pod runs.
+package query
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds a query when its context has no deadline.
+const DefaultTimeout = 5 * time.Second
+
+// maxMinecraftPacket is larger than any status response a server sends.
+const maxMinecraftPacket = 1 << 21
+
+// MinecraftStatus is what a Minecraft Java server answers to a Server List
+// Ping.
+type MinecraftStatus struct {
+	Version       string
+	Protocol      int
+	OnlinePlayers int
+	MaxPlayers    int
+	// Players is the sample of online players the server chose to list
+	Players []string
+	MOTD    string
+}
+
+type minecraftResponse struct {
+	Version struct {
+		Name     string `json:"name"`
+		Protocol int    `json:"protocol"`
+	} `json:"version"`
+	Players struct {
+		Max    int `json:"max"`
+		Online int `json:"online"`
+		Sample []struct {
+			Name string `json:"name"`
+		} `json:"sample"`
+	} `json:"players"`
+	Description json.RawMessage `json:"description"`
+}
+
+// PingMinecraft sends a handshake and a status request to the Minecraft Java
+// server at address (host:port) and returns its answer.
+func PingMinecraft(ctx context.Context, address string) (*MinecraftStatus, error) {
+	host, portString, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := strconv.ParseUint(portString, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q: %w", portString, err)
+	}
+
+	conn, err := dial(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// handshake: protocol version -1 asks for the server's own, next state
+	// 1 is status
+	var handshake bytes.Buffer
+	writeVarInt(&handshake, 0x00)
+	writeVarInt(&handshake, -1)
+	writeString(&handshake, host)
+	_ = binary.Write(&handshake, binary.BigEndian, uint16(port))
+	writeVarInt(&handshake, 1)
+
+	if err := writePacket(conn, handshake.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to send handshake: %w", err)
+	}
+
+	if err := writePacket(conn, []byte{0x00}); err != nil {
+		return nil, fmt.Errorf("failed to send status request: %w", err)
+	}
+
+	packet, err := readPacket(bufio.NewReader(conn))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status response: %w", err)
+	}
+
+	reader := bytes.NewReader(packet)
+
+	packetID, err := readVarInt(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if packetID != 0x00 {
+		return nil, fmt.Errorf("unexpected packet 0x%02x in place of a status response", packetID)
+	}
+
+	payload, err := readString(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var response minecraftResponse
+	if err := json.Unmarshal([]byte(payload), &response); err != nil {
+		return nil, fmt.Errorf("malformed status response: %w", err)
+	}
+
+	status := &MinecraftStatus{
+		Version:       response.Version.Name,
+		Protocol:      response.Version.Protocol,
+		OnlinePlayers: response.Players.Online,
+		MaxPlayers:    response.Players.Max,
+		MOTD:          stripFormatting(chatText(response.Description)),
+	}
+
+	for _, player := range response.Players.Sample {
+		status.Players = append(status.Players, player.Name)
+	}
+
+	return status, nil
+}
+
+// chatText flattens a chat component, which is either a plain string or an
+// object with text and extra components, into its text.
+func chatText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+
+	var component struct {
+		Text  string            `json:"text"`
+		Extra []json.RawMessage `json:"extra"`
+	}
+
+	if err := json.Unmarshal(raw, &component); err != nil {
+		return ""
+	}
+
+	var flattened strings.Builder
+
+	flattened.WriteString(component.Text)
+
+	for _, extra := range component.Extra {
+		flattened.WriteString(chatText(extra))
+	}
+
+	return flattened.String()
+}
+
+// stripFormatting drops the legacy § colour and style codes from a text.
+func stripFormatting(text string) string {
+	var stripped strings.Builder
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '§' {
+			i++
+
+			continue
+		}
+
+		stripped.WriteRune(runes[i])
+	}
+
+	return strings.TrimSpace(stripped.String())
+}
+
+// dial connects to address, bounding the whole exchange by the deadline of
+// ctx or DefaultTimeout.
+func dial(ctx context.Context, network, address string) (net.Conn, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultTimeout)
+	}
+
+	dialer := net.Dialer{Deadline: deadline}
+
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func writePacket(w io.Writer, data []byte) error {
+	var packet bytes.Buffer
+	writeVarInt(&packet, int32(len(data)))
+	packet.Write(data)
+
+	_, err := w.Write(packet.Bytes())
+
+	return err
+}
+
+func readPacket(r *bufio.Reader) ([]byte, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if length <= 0 || length > maxMinecraftPacket {
+		return nil, fmt.Errorf("invalid packet length %d", length)
+	}
+
+	packet := make([]byte, length)
+	if _, err := io.ReadFull(r, packet); err != nil {
+		return nil, err
+	}
+
+	return packet, nil
+}
+
+func writeVarInt(buf *bytes.Buffer, value int32) {
+	unsigned := uint32(value)
+
+	for {
+		if unsigned&^0x7F == 0 {
+			buf.WriteByte(byte(unsigned))
+
+			return
+		}
+
+		buf.WriteByte(byte(unsigned&0x7F | 0x80))
+		unsigned >>= 7
+	}
+}
+
+func readVarInt(r io.ByteReader) (int32, error) {
+	var value uint32
+
+	for shift := 0; shift < 35; shift += 7 {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		value |= uint32(b&0x7F) << shift
+
+		if b&0x80 == 0 {
+			return int32(value), nil
+		}
+	}
+
+	return 0, errors.New("varint is too long")
+}
+
+func writeString(buf *bytes.Buffer, value string) {
+	writeVarInt(buf, int32(len(value)))
+	buf.WriteString(value)
+}
+
+func readString(r *bytes.Reader) (string, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return "", err
+	}
+
+	if length < 0 || int(length) > r.Len() {
+		return "", fmt.Errorf("invalid string length %d", length)
+	}
+
+	value := make([]byte, length)
+	if _, err := io.ReadFull(r, value); err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
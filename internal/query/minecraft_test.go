@@ -0,0 +1,195 @@
+package query
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVarInt(t *testing.T) {
+	tests := []struct {
+		value   int32
+		encoded []byte
+	}{
+		{0, []byte{0x00}},
+		{1, []byte{0x01}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x80, 0x01}},
+		{255, []byte{0xff, 0x01}},
+		{25565, []byte{0xdd, 0xc7, 0x01}},
+		{2147483647, []byte{0xff, 0xff, 0xff, 0xff, 0x07}},
+		{-1, []byte{0xff, 0xff, 0xff, 0xff, 0x0f}},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		writeVarInt(&buf, test.value)
+
+		if !bytes.Equal(buf.Bytes(), test.encoded) {
+			t.Errorf("writeVarInt(%d) = % x, want % x", test.value, buf.Bytes(), test.encoded)
+		}
+
+		value, err := readVarInt(bytes.NewReader(test.encoded))
+		if err != nil || value != test.value {
+			t.Errorf("readVarInt(% x) = %d, %v, want %d", test.encoded, value, err, test.value)
+		}
+	}
+
+	if _, err := readVarInt(bytes.NewReader([]byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x01})); err == nil {
+		t.Error("readVarInt accepted a varint longer than 5 bytes")
+	}
+
+	if _, err := readVarInt(bytes.NewReader([]byte{0x80})); err == nil {
+		t.Error("readVarInt accepted a truncated varint")
+	}
+}
+
+// serveMinecraft answers one Server List Ping with response, checking the
+// handshake and status request on the way.
+func serveMinecraft(t *testing.T, response []byte) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	t.Cleanup(func() { listener.Close() })
+
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		_ = conn.SetDeadline(time.Now().Add(DefaultTimeout))
+
+		r := bufio.NewReader(conn)
+
+		handshake, err := readPacket(r)
+		if err != nil {
+			t.Errorf("reading handshake: %v", err)
+
+			return
+		}
+
+		reader := bytes.NewReader(handshake)
+
+		packetID, _ := readVarInt(reader)
+		protocol, _ := readVarInt(reader)
+		host, _ := readString(reader)
+
+		var serverPort uint16
+		_ = binary.Read(reader, binary.BigEndian, &serverPort)
+		nextState, _ := readVarInt(reader)
+
+		if packetID != 0x00 || protocol != -1 || host != "127.0.0.1" || int(serverPort) != port || nextState != 1 {
+			t.Errorf("unexpected handshake: packet 0x%02x protocol %d host %q port %d next state %d",
+				packetID, protocol, host, serverPort, nextState)
+		}
+
+		request, err := readPacket(r)
+		if err != nil || !bytes.Equal(request, []byte{0x00}) {
+			t.Errorf("unexpected status request % x: %v", request, err)
+
+			return
+		}
+
+		_ = writePacket(conn, response)
+	}()
+
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+}
+
+func statusResponse(payload string) []byte {
+	var packet bytes.Buffer
+	writeVarInt(&packet, 0x00)
+	writeString(&packet, payload)
+
+	return packet.Bytes()
+}
+
+func TestPingMinecraft(t *testing.T) {
+	payload, err := json.Marshal(map[string]any{
+		"version": map[string]any{"name": "1.21.1", "protocol": 767},
+		"players": map[string]any{
+			"max":    20,
+			"online": 2,
+			"sample": []map[string]any{
+				{"name": "Alice", "id": "4566e69f-c907-48ee-8d71-d7ba5aa00d20"},
+				{"name": "Bob", "id": "0e4a3c8e-21d4-4a5e-9e3d-e7d6b2b7c1aa"},
+			},
+		},
+		"description": map[string]any{
+			"text":  "§aA ",
+			"extra": []any{"Minecraft ", map[string]any{"text": "§lServer"}},
+		},
+		// pads the response past 127 bytes, so its length takes two bytes
+		"favicon": "data:image/png;base64," + strings.Repeat("A", 200),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address := serveMinecraft(t, statusResponse(string(payload)))
+
+	status, err := PingMinecraft(context.Background(), address)
+	if err != nil {
+		t.Fatalf("PingMinecraft: %v", err)
+	}
+
+	if status.Version != "1.21.1" || status.Protocol != 767 {
+		t.Errorf("version = %q protocol %d, want 1.21.1 protocol 767", status.Version, status.Protocol)
+	}
+
+	if status.OnlinePlayers != 2 || status.MaxPlayers != 20 {
+		t.Errorf("players = %d/%d, want 2/20", status.OnlinePlayers, status.MaxPlayers)
+	}
+
+	if strings.Join(status.Players, ",") != "Alice,Bob" {
+		t.Errorf("sample = %v, want [Alice Bob]", status.Players)
+	}
+
+	if status.MOTD != "A Minecraft Server" {
+		t.Errorf("MOTD = %q, want %q", status.MOTD, "A Minecraft Server")
+	}
+}
+
+func TestPingMinecraftPlainDescription(t *testing.T) {
+	address := serveMinecraft(t, statusResponse(`{"version":{"name":"1.8.9","protocol":47},"players":{"max":10,"online":0},"description":"§6Old server"}`))
+
+	status, err := PingMinecraft(context.Background(), address)
+	if err != nil {
+		t.Fatalf("PingMinecraft: %v", err)
+	}
+
+	if status.MOTD != "Old server" || status.OnlinePlayers != 0 || status.Players != nil {
+		t.Errorf("status = %+v, want MOTD %q and nobody online", status, "Old server")
+	}
+}
+
+func TestPingMinecraftRejects(t *testing.T) {
+	tests := map[string][]byte{
+		"wrong packet":   {0x01, 0x02, '{', '}'},
+		"malformed JSON": statusResponse("{"),
+		"long string":    {0x00, 0x10, '{', '}'},
+	}
+
+	for name, response := range tests {
+		address := serveMinecraft(t, response)
+
+		if _, err := PingMinecraft(context.Background(), address); err == nil {
+			t.Errorf("%s: PingMinecraft accepted the response", name)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Package rcon is a client of the Source RCON protocol, which Minecraft and
+// Source engine servers expose their console over.
+package rcon
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Packet types, SERVERDATA_AUTH_RESPONSE shares its value with
+// SERVERDATA_EXECCOMMAND.
+const (
+	typeAuth         = 3
+	typeAuthResponse = 2
+	typeExecCommand  = 2
+	typeResponse     = 0
+)
+
+const (
+	// DefaultTimeout bounds a command when its context has no deadline
+	DefaultTimeout = 10 * time.Second
+
+	// maxPacket is the largest packet servers send, commands can't be
+	// longer either
+	maxPacket = 4096 + 10
+)
+
+// ErrAuthFailed is returned when the server rejects the password.
+var ErrAuthFailed = errors.New("rcon authentication failed")
+
+// Client is an authenticated RCON connection. Commands are serialized, it is
+// safe for concurrent use.
+type Client struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID int32
+}
+
+// Dial connects to the RCON port at address (host:port) and authenticates
+// with password.
+func Dial(ctx context.Context, address, password string) (*Client, error) {
+	dialer := net.Dialer{Timeout: DefaultTimeout}
+
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &Client{conn: conn, reader: bufio.NewReader(conn), nextID: 1}
+
+	if err := client.authenticate(ctx, password); err != nil {
+		conn.Close()
+
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Command runs a console command and returns its output.
+func (c *Client) Command(ctx context.Context, command string) (string, error) {
+	if len(command) > maxPacket-10 {
+		return "", fmt.Errorf("command of %d bytes is too long", len(command))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.setDeadline(ctx); err != nil {
+		return "", err
+	}
+
+	id := c.id()
+	if err := c.write(id, typeExecCommand, command); err != nil {
+		return "", err
+	}
+
+	// long output is split over several packets with nothing marking the
+	// last one, but servers answer packets in order, so the answer to an
+	// empty packet sent after the command follows its last one
+	sentinel := c.id()
+	if err := c.write(sentinel, typeResponse, ""); err != nil {
+		return "", err
+	}
+
+	var output bytes.Buffer
+
+	for {
+		packetID, _, body, err := c.read()
+		if err != nil {
+			return "", err
+		}
+
+		switch packetID {
+		case id:
+			output.WriteString(body)
+		case sentinel:
+			return output.String(), nil
+		}
+	}
+}
+
+func (c *Client) authenticate(ctx context.Context, password string) error {
+	if err := c.setDeadline(ctx); err != nil {
+		return err
+	}
+
+	id := c.id()
+	if err := c.write(id, typeAuth, password); err != nil {
+		return err
+	}
+
+	for {
+		packetID, packetType, _, err := c.read()
+		if err != nil {
+			return err
+		}
+
+		// Source servers send an empty response value ahead of the auth
+		// response
+		if packetType != typeAuthResponse {
+			continue
+		}
+
+		if packetID != id {
+			return ErrAuthFailed
+		}
+
+		return nil
+	}
+}
+
+func (c *Client) id() int32 {
+	id := c.nextID
+	c.nextID++
+
+	return id
+}
+
+func (c *Client) setDeadline(ctx context.Context) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultTimeout)
+	}
+
+	return c.conn.SetDeadline(deadline)
+}
+
+func (c *Client) write(id, packetType int32, body string) error {
+	var packet bytes.Buffer
+
+	_ = binary.Write(&packet, binary.LittleEndian, int32(len(body)+10))
+	_ = binary.Write(&packet, binary.LittleEndian, id)
+	_ = binary.Write(&packet, binary.LittleEndian, packetType)
+	packet.WriteString(body)
+	packet.Write([]byte{0x00, 0x00})
+
+	_, err := c.conn.Write(packet.Bytes())
+
+	return err
+}
+
+func (c *Client) read() (int32, int32, string, error) {
+	var size int32
+	if err := binary.Read(c.reader, binary.LittleEndian, &size); err != nil {
+		return 0, 0, "", err
+	}
+
+	if size < 10 || size > maxPacket {
+		return 0, 0, "", fmt.Errorf("invalid packet size %d", size)
+	}
+
+	packet := make([]byte, size)
+	if _, err := io.ReadFull(c.reader, packet); err != nil {
+		return 0, 0, "", err
+	}
+
+	id := int32(binary.LittleEndian.Uint32(packet[0:4]))
+	packetType := int32(binary.LittleEndian.Uint32(packet[4:8]))
+	body := bytes.TrimRight(packet[8:], "\x00")
+
+	return id, packetType, string(body), nil
+}
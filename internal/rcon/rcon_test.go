@@ -0,0 +1,166 @@
+package rcon
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testPassword = "hunter2"
+
+type packet struct {
+	id         int32
+	packetType int32
+	body       string
+}
+
+func readPacket(r io.Reader) (packet, error) {
+	var size int32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return packet{}, err
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return packet{}, err
+	}
+
+	return packet{
+		id:         int32(binary.LittleEndian.Uint32(data[0:4])),
+		packetType: int32(binary.LittleEndian.Uint32(data[4:8])),
+		body:       string(bytes.TrimRight(data[8:], "\x00")),
+	}, nil
+}
+
+func writePacket(w io.Writer, p packet) {
+	var data bytes.Buffer
+
+	_ = binary.Write(&data, binary.LittleEndian, int32(len(p.body)+10))
+	_ = binary.Write(&data, binary.LittleEndian, p.id)
+	_ = binary.Write(&data, binary.LittleEndian, p.packetType)
+	data.WriteString(p.body)
+	data.Write([]byte{0x00, 0x00})
+
+	_, _ = w.Write(data.Bytes())
+}
+
+// serveRCON accepts one connection and answers it like a Source server: an
+// empty response value ahead of every auth response, output longer than
+// chunk split over several packets, and commands looked up in outputs.
+func serveRCON(t *testing.T, chunk int, outputs map[string]string) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		_ = conn.SetDeadline(time.Now().Add(DefaultTimeout))
+
+		reader := bufio.NewReader(conn)
+
+		for {
+			request, err := readPacket(reader)
+			if err != nil {
+				return
+			}
+
+			switch request.packetType {
+			case typeAuth:
+				writePacket(conn, packet{id: request.id, packetType: typeResponse})
+
+				if request.body != testPassword {
+					writePacket(conn, packet{id: -1, packetType: typeAuthResponse})
+
+					continue
+				}
+
+				writePacket(conn, packet{id: request.id, packetType: typeAuthResponse})
+			case typeExecCommand:
+				output := outputs[request.body]
+
+				for len(output) > chunk {
+					writePacket(conn, packet{id: request.id, packetType: typeResponse, body: output[:chunk]})
+					output = output[chunk:]
+				}
+
+				writePacket(conn, packet{id: request.id, packetType: typeResponse, body: output})
+			case typeResponse:
+				// Source servers mirror an empty response value, then add
+				// one of their own
+				writePacket(conn, packet{id: request.id, packetType: typeResponse})
+				writePacket(conn, packet{id: request.id, packetType: typeResponse, body: "\x00\x01"})
+			}
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestAuthFailed(t *testing.T) {
+	address := serveRCON(t, maxPacket, nil)
+
+	_, err := Dial(context.Background(), address, "wrong")
+	if !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("Dial with a wrong password = %v, want ErrAuthFailed", err)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	long := strings.Repeat("0123456789abcdef", 1000)
+
+	address := serveRCON(t, 4096, map[string]string{
+		"list":  "There are 1 of a max of 20 players online: Alice",
+		"dump":  long,
+		"empty": "",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := Dial(ctx, address, testPassword)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	for _, test := range []struct {
+		command string
+		want    string
+	}{
+		{"list", "There are 1 of a max of 20 players online: Alice"},
+		{"dump", long},
+		{"empty", ""},
+		// the leftovers of the sentinel before don't end up in this output
+		{"list", "There are 1 of a max of 20 players online: Alice"},
+	} {
+		output, err := client.Command(ctx, test.command)
+		if err != nil {
+			t.Fatalf("Command(%q): %v", test.command, err)
+		}
+
+		if output != test.want {
+			t.Errorf("Command(%q) = %d bytes, want %d bytes", test.command, len(output), len(test.want))
+		}
+	}
+
+	if _, err := client.Command(ctx, strings.Repeat("x", maxPacket)); err == nil {
+		t.Error("Command sent a command longer than a packet")
+	}
+}
@@ -0,0 +1,64 @@
+package reconciler
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// retryInitialDelay is how long a GameServer whose release operation
+	// failed is left alone, doubling with every failure in a row
+	retryInitialDelay = 10 * time.Second
+
+	// retryMaxDelay caps the delay between retries
+	retryMaxDelay = 10 * time.Minute
+)
+
+// backoff spaces out the release operations of GameServers that keep
+// failing. The zero value is ready to use.
+type backoff struct {
+	mu      sync.Mutex
+	retries map[string]retry
+}
+
+type retry struct {
+	failures int
+	next     time.Time
+}
+
+// waiting reports whether the GameServer under key failed recently enough
+// that it shouldn't be retried yet.
+func (b *backoff) waiting(key string, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	retry, ok := b.retries[key]
+
+	return ok && now.Before(retry.next)
+}
+
+// record notes the outcome of a release operation, delaying the next one
+// after a failure and forgetting earlier failures after a success.
+func (b *backoff) record(key string, err error, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		delete(b.retries, key)
+
+		return
+	}
+
+	if b.retries == nil {
+		b.retries = make(map[string]retry)
+	}
+
+	failures := b.retries[key].failures + 1
+
+	delay := retryInitialDelay
+	for i := 1; i < failures && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+
+	b.retries[key] = retry{failures: failures, next: now.Add(min(delay, retryMaxDelay))}
+}
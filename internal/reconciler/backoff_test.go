@@ -0,0 +1,48 @@
+package reconciler
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	var b backoff
+
+	now := time.Now()
+	failed := errors.New("upgrade failed")
+
+	if b.waiting("games/survival", now) {
+		t.Fatal("waiting before any failure")
+	}
+
+	for _, want := range []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, 80 * time.Second} {
+		b.record("games/survival", failed, now)
+
+		if !b.waiting("games/survival", now.Add(want-time.Millisecond)) {
+			t.Errorf("retried before %s", want)
+		}
+
+		if b.waiting("games/survival", now.Add(want)) {
+			t.Errorf("still waiting after %s", want)
+		}
+	}
+
+	if b.waiting("games/creative", now) {
+		t.Error("another GameServer waits on this one's failures")
+	}
+
+	for range 20 {
+		b.record("games/survival", failed, now)
+	}
+
+	if b.waiting("games/survival", now.Add(retryMaxDelay)) {
+		t.Errorf("waiting past %s", retryMaxDelay)
+	}
+
+	b.record("games/survival", nil, now)
+
+	if b.waiting("games/survival", now) {
+		t.Error("waiting after a success")
+	}
+}
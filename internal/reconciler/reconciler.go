@@ -3,30 +3,38 @@ package reconciler
 import (
 	"context"
 	"fmt"
-	"os"
 	"time"
 
 	"go.uber.org/zap"
-	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/cli"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 
 	"github.com/Sackbuoy/gameserver-operator/internal/crds"
-	"github.com/Sackbuoy/gameserver-operator/internal/util"
 	"github.com/Sackbuoy/gameserver-operator/internal/manager"
 )
 
+// releaseManager is the part of the manager the reconciler drives.
+type releaseManager interface {
+	Installed(gameServer *crds.GameServer) (bool, error)
+	Create(ctx context.Context, obj map[string]any, namespace string) error
+	Update(ctx context.Context, obj map[string]any, namespace string) error
+	Delete(ctx context.Context, obj map[string]any, namespace string) error
+}
+
 type Reconciler struct {
 	helmSettings *cli.EnvSettings
 	logger       *zap.Logger
 	k8sClient    *dynamic.DynamicClient
 	loopInterval time.Duration
 	instanceMap  *crds.CRDInstanceMap
-	manager      *manager.Manager
+	manager      releaseManager
 	logOutput    func(string, ...any)
-  gvc schema.GroupVersionResource
+	gvc          schema.GroupVersionResource
+	backoff      backoff
 }
 
 func New(ctx context.Context, logger *zap.Logger, manager *manager.Manager, k8sClient *dynamic.DynamicClient, gvc schema.GroupVersionResource, instanceMap *crds.CRDInstanceMap) (*Reconciler, error) {
@@ -43,72 +51,124 @@ func New(ctx context.Context, logger *zap.Logger, manager *manager.Manager, k8sC
 		manager:      manager,
 		helmSettings: settings,
 		logOutput:    logOutput,
-    gvc: gvc,
-    instanceMap: instanceMap,
-    k8sClient: k8sClient,
+		gvc:          gvc,
+		instanceMap:  instanceMap,
+		k8sClient:    k8sClient,
 	}, nil
 }
 
 func (r *Reconciler) MonitorLoop(ctx context.Context, namespace string) {
 	ticker := time.NewTicker(r.loopInterval)
 
-	actionConfig := new(action.Configuration)
-	if err := actionConfig.Init(r.helmSettings.RESTClientGetter(), namespace, os.Getenv("HELM_DRIVER"), r.logOutput); err != nil {
-		r.logger.Error("Failed to initialize Helm Action Config", zap.Error(err))
-	}
-
 	for {
 		select {
 		case <-ticker.C:
-      err := r.reconcile(ctx, *actionConfig)
-      if err != nil {
-        r.logger.Error("Error listing existing games", zap.Error(err))
-      }
+			err := r.reconcile(ctx)
+			if err != nil {
+				r.logger.Error("Error listing existing games", zap.Error(err))
+			}
 			// loop through currently tracked CRDs, check if the helm chart is installed
 		}
 	}
 }
 
-func (r *Reconciler) reconcile(ctx context.Context, actionConfig action.Configuration) error {
+func (r *Reconciler) reconcile(ctx context.Context) error {
 	unstructuredList, err := r.k8sClient.Resource(r.gvc).Namespace("").List(ctx, metav1.ListOptions{})
-  if err != nil {
-    return err
-  }
-
-  client := action.NewGet(&actionConfig)
-	
-	for _, instance := range unstructuredList.Items {
-		var newCRD crds.GameServer
-    releaseName := instance.GetName()
-
-		util.MapUnstructuredToStruct(&instance, &newCRD)
-		err = r.instanceMap.Create(&newCRD)
-    if err != nil {
-      return err
-    }
-
-    // Try to get the release
-    release, err := client.Run(releaseName)
-    switch release {
-    case nil:
-      // if release is nil and err isn't helm chart is not installed
-      if err != nil {
-        r.logger.Info("No Helm chart release found. Installing...", zap.String("Instance", releaseName))
-        r.manager.Create(instance.Object, newCRD.Spec.GameType, releaseName, instance.GetNamespace())
-      } else {
-        // this would be weird, should never happen
-        r.logger.Error("No helm chart release found, but no error was returned", zap.String("Instance", releaseName))
-      }
-    default:
-      if err != nil {
-        // this would be weird, should never happen
-        r.logger.Error("Helm chart release found, but error was returned", zap.String("Instance", releaseName), zap.Error(err))
-      } else {
-        // chart exists, no action needed
-        continue
-      }
-    }
+	if err != nil {
+		return err
+	}
+
+	for i := range unstructuredList.Items {
+		instance := &unstructuredList.Items[i]
+
+		newCRD, err := decodeGameServer(instance)
+		if err != nil {
+			r.logger.Error("Failed to decode GameServer", zap.String("Instance", instance.GetName()), zap.Error(err))
+
+			continue
+		}
+
+		err = r.instanceMap.Create(newCRD)
+		if err != nil {
+			return err
+		}
+
+		r.reconcileInstance(ctx, instance, newCRD)
 	}
 
-  return nil
+	return nil
+}
+
+// decodeGameServer converts a listed GameServer, metadata and status included:
+// the release name and the installed check depend on them.
+func decodeGameServer(instance *unstructured.Unstructured) (*crds.GameServer, error) {
+	var gameServer crds.GameServer
+
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(instance.Object, &gameServer); err != nil {
+		return nil, err
+	}
+
+	return &gameServer, nil
+}
+
+// reconcileInstance installs the release of a GameServer when there is none
+// yet, upgrades it otherwise, and runs its deletion policy once it is being
+// deleted.
+func (r *Reconciler) reconcileInstance(ctx context.Context, instance *unstructured.Unstructured, gameServer *crds.GameServer) {
+	key := instance.GetNamespace() + "/" + instance.GetName()
+
+	// a release operation that keeps failing is retried less and less often
+	if r.backoff.waiting(key, time.Now()) {
+		return
+	}
+
+	// deletions missed by the watch are still held by the finalizer
+	if gameServer.DeletionTimestamp != nil {
+		go func() {
+			err := r.manager.Delete(ctx, instance.Object, instance.GetNamespace())
+			r.backoff.record(key, err, time.Now())
+
+			if err != nil {
+				r.logger.Error("Failed to delete Helm chart release", zap.String("Instance", instance.GetName()), zap.Error(err))
+			}
+		}()
+
+		return
+	}
+
+	// releases live in the GameServer's own storage driver, so ask the
+	// manager. Without a release name there is nothing to look up, the
+	// watcher's Create reports invalid names on the GameServer.
+	installed, err := r.manager.Installed(gameServer)
+	if err != nil {
+		r.logger.Error("Failed to look up Helm chart release", zap.String("Instance", instance.GetName()), zap.Error(err))
+
+		return
+	}
+
+	if !installed {
+		r.logger.Info("No Helm chart release found. Installing...", zap.String("Instance", instance.GetName()))
+		// installs and upgrades wait for readiness, run them off the loop so
+		// one slow GameServer doesn't hold up the others
+		go func() {
+			err := r.manager.Create(ctx, instance.Object, instance.GetNamespace())
+			r.backoff.record(key, err, time.Now())
+
+			if err != nil {
+				r.logger.Error("Failed to install Helm chart release", zap.String("Instance", instance.GetName()), zap.Error(err))
+			}
+		}()
+
+		return
+	}
+
+	// chart exists, upgrade it if the spec or its values changed
+	go func() {
+		err := r.manager.Update(ctx, instance.Object, instance.GetNamespace())
+		r.backoff.record(key, err, time.Now())
+
+		if err != nil {
+			r.logger.Error("Failed to update Helm chart release", zap.String("Instance", instance.GetName()), zap.Error(err))
+		}
+	}()
 }
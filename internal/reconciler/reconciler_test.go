@@ -0,0 +1,119 @@
+package reconciler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+)
+
+// fakeManager reports a release as installed for GameServers whose status
+// records one, the way a release found under its recorded name would be.
+type fakeManager struct {
+	calls chan string
+}
+
+func (f *fakeManager) Installed(gameServer *crds.GameServer) (bool, error) {
+	return gameServer.Namespace == "games" && gameServer.Name == "survival" &&
+		gameServer.Status.HelmRelease != nil && gameServer.Status.HelmRelease.Name == "survival", nil
+}
+
+func (f *fakeManager) Create(ctx context.Context, obj map[string]any, namespace string) error {
+	f.calls <- "Create"
+
+	return nil
+}
+
+func (f *fakeManager) Update(ctx context.Context, obj map[string]any, namespace string) error {
+	f.calls <- "Update"
+
+	return nil
+}
+
+func (f *fakeManager) Delete(ctx context.Context, obj map[string]any, namespace string) error {
+	f.calls <- "Delete"
+
+	return nil
+}
+
+func TestReconcileUpdatesInstalledRelease(t *testing.T) {
+	instance := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "goopy.us/v1",
+		"kind":       "GameServer",
+		"metadata": map[string]any{
+			"name":      "survival",
+			"namespace": "games",
+		},
+		"spec": map[string]any{
+			"gameType": "minecraft-java",
+		},
+		"status": map[string]any{
+			"helmRelease": map[string]any{
+				"name":    "survival",
+				"version": int64(3),
+			},
+		},
+	}}
+
+	gameServer, err := decodeGameServer(instance)
+	if err != nil {
+		t.Fatalf("decodeGameServer: %v", err)
+	}
+
+	if gameServer.Name != "survival" || gameServer.Namespace != "games" {
+		t.Fatalf("metadata not decoded: %q/%q", gameServer.Namespace, gameServer.Name)
+	}
+
+	manager := &fakeManager{calls: make(chan string, 1)}
+	r := &Reconciler{logger: zap.NewNop(), manager: manager}
+
+	r.reconcileInstance(context.Background(), instance, gameServer)
+
+	select {
+	case call := <-manager.calls:
+		if call != "Update" {
+			t.Fatalf("installed release went through %s, want Update", call)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("neither Create nor Update was called")
+	}
+}
+
+func TestReconcileDeletesGameServersBeingDeleted(t *testing.T) {
+	instance := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "goopy.us/v1",
+		"kind":       "GameServer",
+		"metadata": map[string]any{
+			"name":              "survival",
+			"namespace":         "games",
+			"deletionTimestamp": "2026-10-19T12:00:00Z",
+			"finalizers":        []any{"goopy.us/release"},
+		},
+		"spec": map[string]any{
+			"gameType": "minecraft-java",
+		},
+	}}
+
+	gameServer, err := decodeGameServer(instance)
+	if err != nil {
+		t.Fatalf("decodeGameServer: %v", err)
+	}
+
+	manager := &fakeManager{calls: make(chan string, 1)}
+	r := &Reconciler{logger: zap.NewNop(), manager: manager}
+
+	r.reconcileInstance(context.Background(), instance, gameServer)
+
+	select {
+	case call := <-manager.calls:
+		if call != "Delete" {
+			t.Fatalf("GameServer being deleted went through %s, want Delete", call)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Delete was not called")
+	}
+}
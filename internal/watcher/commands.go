@@ -0,0 +1,104 @@
+package watcher
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/crds"
+	"github.com/Sackbuoy/gameserver-operator/internal/manager"
+)
+
+// CommandWatcher watches GameServerCommands and asks the manager to run the
+// ones that haven't run yet.
+type CommandWatcher struct {
+	k8sClient *dynamic.DynamicClient
+	logger    *zap.Logger
+	manager   *manager.Manager
+}
+
+func NewCommandWatcher(logger *zap.Logger, k8sClient *dynamic.DynamicClient, manager *manager.Manager) *CommandWatcher {
+	return &CommandWatcher{
+		k8sClient: k8sClient,
+		logger:    logger,
+		manager:   manager,
+	}
+}
+
+// Watch blocks until ctx is done, re-establishing the watch as the API
+// server expires it, and backing off while it fails. Commands created while
+// nobody watched run on the next list, and ones left Running are failed.
+func (w *CommandWatcher) Watch(ctx context.Context) {
+	client := w.k8sClient.Resource(crds.GameServerCommandResource).Namespace("")
+	delay := retryInitialDelay
+
+	for ctx.Err() == nil {
+		list, err := client.List(ctx, metav1.ListOptions{})
+		if err != nil {
+			w.logger.Error("Failed to list GameServerCommands", zap.Error(err))
+			waitRetry(ctx, &delay)
+
+			continue
+		}
+
+		for i := range list.Items {
+			w.run(ctx, &list.Items[i])
+		}
+
+		watcher, err := client.Watch(ctx, metav1.ListOptions{ResourceVersion: list.GetResourceVersion()})
+		if err != nil {
+			w.logger.Error("Failed to watch GameServerCommands", zap.Error(err))
+			waitRetry(ctx, &delay)
+
+			continue
+		}
+
+		started := time.Now()
+
+		for event := range watcher.ResultChan() {
+			if event.Type == watch.Error {
+				w.logger.Error("Watch of GameServerCommands failed", zap.Error(apierrors.FromObject(event.Object)))
+
+				continue
+			}
+
+			obj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok || event.Type != watch.Added {
+				continue
+			}
+
+			w.run(ctx, obj)
+		}
+
+		watcher.Stop()
+
+		// the API server expires watches after minutes, ones closed right
+		// away failed
+		if time.Since(started) < minWatchDuration {
+			waitRetry(ctx, &delay)
+
+			continue
+		}
+
+		delay = retryInitialDelay
+	}
+}
+
+// run runs a command off the watch, commands wait on the game server.
+func (w *CommandWatcher) run(ctx context.Context, obj *unstructured.Unstructured) {
+	go func() {
+		err := w.manager.RunCommand(ctx, obj.Object)
+		if err != nil {
+			w.logger.Error("Failed to run GameServerCommand",
+				zap.String("Namespace", obj.GetNamespace()),
+				zap.String("Name", obj.GetName()),
+				zap.Error(err))
+		}
+	}()
+}
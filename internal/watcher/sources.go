@@ -0,0 +1,130 @@
+package watcher
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/Sackbuoy/gameserver-operator/internal/manager"
+)
+
+// SourceWatcher watches the Secrets and ConfigMaps GameServers can pull chart
+// values from, and asks the manager to resync the GameServers referencing
+// an object whenever it changes.
+type SourceWatcher struct {
+	k8sClient *dynamic.DynamicClient
+	logger    *zap.Logger
+	manager   *manager.Manager
+}
+
+var sourceKinds = map[string]schema.GroupVersionResource{
+	"Secret":    {Version: "v1", Resource: "secrets"},
+	"ConfigMap": {Version: "v1", Resource: "configmaps"},
+}
+
+func NewSourceWatcher(logger *zap.Logger, k8sClient *dynamic.DynamicClient, manager *manager.Manager) *SourceWatcher {
+	return &SourceWatcher{
+		k8sClient: k8sClient,
+		logger:    logger,
+		manager:   manager,
+	}
+}
+
+// Watch blocks until ctx is done, re-establishing watches as the API server
+// expires them, and backing off while it fails.
+func (w *SourceWatcher) Watch(ctx context.Context) {
+	for kind, resource := range sourceKinds {
+		go w.watchKind(ctx, kind, resource)
+	}
+
+	<-ctx.Done()
+}
+
+func (w *SourceWatcher) watchKind(ctx context.Context, kind string, resource schema.GroupVersionResource) {
+	client := w.k8sClient.Resource(resource).Namespace("")
+	delay := retryInitialDelay
+
+	for ctx.Err() == nil {
+		// start from the current resource version so existing objects are not
+		// replayed as ADDED events
+		list, err := client.List(ctx, metav1.ListOptions{Limit: 1})
+		if err != nil {
+			w.logger.Error("Failed to list values sources", zap.String("Kind", kind), zap.Error(err))
+			waitRetry(ctx, &delay)
+
+			continue
+		}
+
+		watcher, err := client.Watch(ctx, metav1.ListOptions{ResourceVersion: list.GetResourceVersion()})
+		if err != nil {
+			w.logger.Error("Failed to watch values sources", zap.String("Kind", kind), zap.Error(err))
+			waitRetry(ctx, &delay)
+
+			continue
+		}
+
+		started := time.Now()
+
+		for event := range watcher.ResultChan() {
+			if event.Type == watch.Error {
+				w.logger.Error("Watch of values sources failed", zap.String("Kind", kind),
+					zap.Error(apierrors.FromObject(event.Object)))
+
+				continue
+			}
+
+			obj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+
+			err := w.manager.SyncValuesSource(ctx, kind, obj)
+			if err != nil {
+				w.logger.Error("Failed to sync values source",
+					zap.String("Kind", kind),
+					zap.String("Name", obj.GetName()),
+					zap.Error(err))
+			}
+		}
+
+		watcher.Stop()
+
+		// the API server expires watches after minutes, ones closed right
+		// away failed
+		if time.Since(started) < minWatchDuration {
+			waitRetry(ctx, &delay)
+
+			continue
+		}
+
+		delay = retryInitialDelay
+	}
+}
+
+const (
+	// retryInitialDelay and retryMaxDelay bound the backoff between attempts
+	// to re-establish a failed watch
+	retryInitialDelay = time.Second
+	retryMaxDelay     = time.Minute
+
+	// minWatchDuration is how long a watch has to last to count as healthy
+	minWatchDuration = 10 * time.Second
+)
+
+// waitRetry waits out delay or until ctx is done, and doubles delay for the
+// next attempt.
+func waitRetry(ctx context.Context, delay *time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(*delay):
+	}
+
+	*delay = min(*delay*2, retryMaxDelay)
+}
@@ -42,7 +42,7 @@ func New(ctx context.Context,
 	}, nil
 }
 
-func (w *Watcher) Watch() error {
+func (w *Watcher) Watch(ctx context.Context) error {
 	for event := range w.watcher.ResultChan() {
 		obj, ok := event.Object.(*unstructured.Unstructured)
 		if !ok {
@@ -77,12 +77,14 @@ func (w *Watcher) Watch() error {
 			// Display some fields from the Game
 			w.logger.Info("Found Game", zap.String("Name", name))
 
-			err = w.manager.Create(obj.Object, crd.Spec.GameType, name, namespace)
-			if err != nil {
-				w.logger.Error("Error creating resources", zap.Error(err))
-
-				continue
-			}
+			// installs wait for the release to become ready, so they must
+			// not hold up events of other GameServers
+			go func() {
+				err := w.manager.Create(ctx, obj.Object, namespace)
+				if err != nil {
+					w.logger.Error("Error creating resources", zap.Error(err))
+				}
+			}()
 		case "MODIFIED":
 			w.logger.Info("CRD Event detected", zap.String("Key", key))
 			w.logger.Info("With Event Type", zap.String("EventType", string(event.Type)))
@@ -90,12 +92,25 @@ func (w *Watcher) Watch() error {
 			// Display some fields from the Game
 			w.logger.Info("Found Game", zap.String("Name", name))
 
-			err = w.manager.Update()
-			if err != nil {
-				w.logger.Error("Error updating resources", zap.Error(err))
+			// the finalizer holds GameServers being deleted until their
+			// deletion policy has run
+			if obj.GetDeletionTimestamp() != nil {
+				go func() {
+					err := w.manager.Delete(ctx, obj.Object, namespace)
+					if err != nil {
+						w.logger.Error("Error deleting resources", zap.Error(err))
+					}
+				}()
 
 				continue
 			}
+
+			go func() {
+				err := w.manager.Update(ctx, obj.Object, namespace)
+				if err != nil {
+					w.logger.Error("Error updating resources", zap.Error(err))
+				}
+			}()
 		case "DELETED":
 			w.logger.Info("CRD Event detected", zap.String("Key", key))
 			w.logger.Info("With Event Type", zap.String("EventType", string(event.Type)))
@@ -103,12 +118,20 @@ func (w *Watcher) Watch() error {
 			// Display some fields from the Game
 			w.logger.Info("Found Game", zap.String("Name", name))
 
-			err = w.manager.Delete(name, namespace)
-			if err != nil {
-				w.logger.Error("Error deleting resources", zap.Error(err))
-
+			// GameServers with the finalizer were handled while it held
+			// them, the others are deleted without one
+			if obj.GetDeletionTimestamp() != nil {
 				continue
 			}
+
+			// deletions stop the game and wait for the uninstall, and take
+			// their turn after operations already running on the release
+			go func() {
+				err := w.manager.Delete(ctx, obj.Object, namespace)
+				if err != nil {
+					w.logger.Error("Error deleting resources", zap.Error(err))
+				}
+			}()
 		}
 	}
 